@@ -23,10 +23,59 @@ type DeriveTransform struct {
 	List ExprList
 }
 
+// SortTransform sorts by the items of List, in descending order for items
+// that are negated, e.g. sort [a, -b]
+type SortTransform struct {
+	List ExprList
+}
+
+type FilterTransform struct {
+	Expr Expr
+}
+
+type TakeTransform struct {
+	Expr Expr
+}
+
+// WindowTransform applies the transforms of Pipeline over a frame of rows
+// around each row, e.g. window rows:-3..0 (derive rolling = average value).
+// Named holds the frame: rows or range, expanding or rolling.
+type WindowTransform struct {
+	Named    []NamedArg
+	Pipeline []Node
+}
+
+// GroupTransform applies the transforms of Pipeline to each group of rows
+// with equal values of By, e.g. group [dept] (sort salary | derive r = rank)
+type GroupTransform struct {
+	By       ExprList
+	Pipeline []Node
+}
+
+// CustomTransform is a transform that is registered with
+// parser.RegisterTransform and parsed with parser.ListTransform, e.g.
+// mask_pii [email, phone]
+type CustomTransform struct {
+	Name Ident
+	List ExprList
+}
+
+// QueryHeader is the optional first statement of a query, e.g.
+// prql dialect:sqlite
+type QueryHeader struct {
+	Args []NamedArg
+}
+
+type NamedArg struct {
+	Name  Ident
+	Value Expr
+}
+
 type BinaryExpr struct {
-	X  Expr
-	Y  Expr
-	Op token.Token
+	X     Expr
+	Y     Expr
+	Op    token.Token
+	OpPos scanner.Pos
 }
 
 type UnaryExpr struct {
@@ -43,20 +92,111 @@ type AssignExpr struct {
 	Expr Expr
 }
 
-type ExprList struct {
+// CallExpr is a call of a standard library function, e.g. round 2 price
+type CallExpr struct {
+	Name  Ident
+	Args  []Expr
+	Named []NamedArg
+}
+
+// CaseExpr is the Value of the first arm whose Cond is true, or null if
+// there is none, e.g. case [score > 90 => "A", true => "C"]
+type CaseExpr struct {
+	Case scanner.Pos // position of the case keyword
+	Arms []CaseArm
+}
+
+type CaseArm struct {
+	Cond  Expr
+	Value Expr
+}
+
+// RangeExpr is a range, e.g. -3..0. Start or End is nil for a range that
+// is unbounded on that side.
+type RangeExpr struct {
+	Start Expr
+	End   Expr
+}
+
+// PipeExpr is a value piped to calls in parentheses, e.g.
+// (amount | round 2). Each call takes the value, or the result of the
+// previous call, as its last argument, so the example is sugar for
+// round 2 amount.
+type PipeExpr struct {
+	X     Expr
+	Calls []CallExpr
+}
+
+// FuncLit is an anonymous function, e.g. x -> x + 1. Functions with more
+// than one parameter are written in parentheses, e.g. (a b -> a + b).
+type FuncLit struct {
+	Params []Ident
+	Body   Expr
+}
+
+// Array is an array literal, e.g. ["US", "CA"]. Unlike the lists of
+// transforms, arrays are values, like the pattern of in.
+type Array struct {
 	Items []Expr
 }
 
+// ExprList is a list of expressions, e.g. the columns of a select. Lists
+// with more than one item are written in brackets, [a, b], or, with Braces,
+// in the newer tuple syntax {a, b}. A list in braces is also a tuple
+// expression.
+type ExprList struct {
+	Items  []Expr
+	Braces bool
+}
+
 type Root struct {
+	Header     *QueryHeader
 	Transforms []Node
+	Comments   CommentMap
 }
 
+type Comment struct {
+	Text string // including the leading '#'
+	Pos  scanner.Pos
+}
+
+type CommentGroup struct {
+	List []Comment
+}
+
+// NodePath identifies a transform in Root.Transforms, or an item of that
+// transform's expression list. The items of the lists of the transforms
+// nested in a window or group transform are numbered after the items of
+// the enclosing transform, in source order.
+//
+// The transforms nested in a transform are numbered from 1 in source order
+// by Nested, and so is the end of each nested pipeline, which holds the
+// comments before its closing parenthesis. For example, in
+// group a (derive b = 1 | window (take 1)), derive is 1, window is 2, take
+// is 3, the end of the window pipeline is 4 and the end of the group
+// pipeline is 5.
+type NodePath struct {
+	Transform int // -1 for the query header
+	Item      int // -1 for the transform itself
+	Nested    int // 0 unless Item is -1 and the path is within a nested pipeline
+}
+
+type NodeComments struct {
+	Leading  *CommentGroup // comments on the lines before the node
+	Trailing *CommentGroup // comment on the line the node ends on
+}
+
+// CommentMap maps nodes to their comments, similar to go/ast.CommentMap.
+// Comments after the last transform are leading comments of the path one
+// past the last transform.
+type CommentMap map[NodePath]NodeComments
+
 type String struct {
 	Value string
 }
 
 type Integer struct {
-	Value int
+	Value int64
 }
 
 type Date struct {
@@ -72,7 +212,7 @@ type Timestamp struct {
 	Hour, Minute, Second int
 }
 type Interval struct {
-	Count int
+	Count int64
 	Unit  string
 }
 
@@ -80,6 +220,10 @@ type Float struct {
 	Value float64
 }
 
+type Boolean struct {
+	Value bool
+}
+
 type Column struct {
 	Name Ident
 }
@@ -96,6 +240,13 @@ type Expr interface {
 func (FromTransform) node()   {}
 func (SelectTransform) node() {}
 func (DeriveTransform) node() {}
+func (FilterTransform) node() {}
+func (SortTransform) node()   {}
+func (TakeTransform) node()   {}
+func (WindowTransform) node() {}
+func (GroupTransform) node()  {}
+func (CustomTransform) node() {}
+func (QueryHeader) node()     {}
 func (ExprList) node()        {}
 func (Root) node()            {}
 func (Column) node()          {}
@@ -106,10 +257,17 @@ func (Timestamp) node()       {}
 func (Interval) node()        {}
 func (String) node()          {}
 func (Float) node()           {}
+func (Boolean) node()         {}
 func (BinaryExpr) node()      {}
 func (UnaryExpr) node()       {}
 func (ParenExpr) node()       {}
 func (AssignExpr) node()      {}
+func (CallExpr) node()        {}
+func (RangeExpr) node()       {}
+func (CaseExpr) node()        {}
+func (Array) node()           {}
+func (PipeExpr) node()        {}
+func (FuncLit) node()         {}
 
 func (Column) expr()     {}
 func (Integer) expr()    {}
@@ -119,7 +277,15 @@ func (Timestamp) expr()  {}
 func (Interval) expr()   {}
 func (String) expr()     {}
 func (Float) expr()      {}
+func (Boolean) expr()    {}
 func (BinaryExpr) expr() {}
 func (UnaryExpr) expr()  {}
 func (ParenExpr) expr()  {}
 func (AssignExpr) expr() {}
+func (CallExpr) expr()   {}
+func (RangeExpr) expr()  {}
+func (CaseExpr) expr()   {}
+func (Array) expr()      {}
+func (PipeExpr) expr()   {}
+func (FuncLit) expr()    {}
+func (ExprList) expr()   {}
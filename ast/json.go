@@ -0,0 +1,426 @@
+package ast
+
+// JSON encoding of the AST. See docs/ast-json.md for the schema.
+//
+// Every node is encoded as an object with a "kind" member holding its type
+// name, so that fields of interface types like Expr can be decoded again.
+// Other members are the node's fields, named after the Go field with a
+// lower-case first letter.
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var kinds = map[string]reflect.Type{}
+
+func init() {
+	for _, node := range []Node{
+		FromTransform{},
+		SelectTransform{},
+		DeriveTransform{},
+		FilterTransform{},
+		SortTransform{},
+		TakeTransform{},
+		WindowTransform{},
+		GroupTransform{},
+		CustomTransform{},
+		QueryHeader{},
+		ExprList{},
+		Root{},
+		Column{},
+		Integer{},
+		Date{},
+		Time{},
+		Timestamp{},
+		Interval{},
+		String{},
+		Float{},
+		Boolean{},
+		BinaryExpr{},
+		UnaryExpr{},
+		ParenExpr{},
+		AssignExpr{},
+		CallExpr{},
+		RangeExpr{},
+		CaseExpr{},
+		Array{},
+		PipeExpr{},
+		FuncLit{},
+	} {
+		var typ = reflect.TypeOf(node)
+		kinds[typ.Name()] = typ
+	}
+}
+
+var (
+	nodeType            = reflect.TypeOf((*Node)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// UnmarshalNode decodes a node of any kind.
+func UnmarshalNode(data []byte) (Node, error) {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		return nil, nil
+	}
+
+	var header struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	var typ, ok = kinds[header.Kind]
+	if !ok {
+		return nil, fmt.Errorf("ast: unknown node kind %q", header.Kind)
+	}
+
+	var v = reflect.New(typ).Elem()
+	if err := decodeStruct(data, v); err != nil {
+		return nil, err
+	}
+	return v.Interface().(Node), nil
+}
+
+func marshalNode(node Node) ([]byte, error) {
+	var b bytes.Buffer
+	if err := encodeStruct(&b, reflect.ValueOf(node)); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func unmarshalNode(data []byte, node Node) error {
+	return decodeStruct(data, reflect.ValueOf(node).Elem())
+}
+
+func fieldKey(name string) string {
+	var r, size = utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
+
+func encodeStruct(b *bytes.Buffer, v reflect.Value) error {
+	b.WriteByte('{')
+	var typ = v.Type()
+	var sep = ""
+	if typ.Implements(nodeType) {
+		fmt.Fprintf(b, `"kind":%q`, typ.Name())
+		sep = ","
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		if !typ.Field(i).IsExported() {
+			continue
+		}
+		fmt.Fprintf(b, `%s%q:`, sep, fieldKey(typ.Field(i).Name))
+		sep = ","
+		if err := encodeValue(b, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	b.WriteByte('}')
+	return nil
+}
+
+func encodeValue(b *bytes.Buffer, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			b.WriteString("null")
+			return nil
+		}
+		return encodeValue(b, v.Elem())
+	case reflect.Struct:
+		return encodeStruct(b, v)
+	case reflect.Slice:
+		if v.IsNil() {
+			b.WriteString("null")
+			return nil
+		}
+		b.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := encodeValue(b, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+		return nil
+	case reflect.Map:
+		if v.IsNil() {
+			b.WriteString("null")
+			return nil
+		}
+		var keys = make([]string, 0, v.Len())
+		var values = map[string]reflect.Value{}
+		var iter = v.MapRange()
+		for iter.Next() {
+			var key, err = iter.Key().Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return err
+			}
+			keys = append(keys, string(key))
+			values[string(key)] = iter.Value()
+		}
+		sortKeys(keys)
+
+		b.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%q:", key)
+			if err := encodeValue(b, values[key]); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+		return nil
+	default:
+		var data, err = json.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+		return nil
+	}
+}
+
+func decodeStruct(data []byte, v reflect.Value) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+
+	var typ = v.Type()
+	if kind, ok := members["kind"]; ok && typ.Implements(nodeType) {
+		var name string
+		if err := json.Unmarshal(kind, &name); err != nil {
+			return err
+		}
+		if name != typ.Name() {
+			return fmt.Errorf("ast: cannot decode %q node into %s", name, typ.Name())
+		}
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		if !typ.Field(i).IsExported() {
+			continue
+		}
+		if raw, ok := members[fieldKey(typ.Field(i).Name)]; ok {
+			if err := decodeValue(raw, v.Field(i)); err != nil {
+				return fmt.Errorf("%s.%s: %w", typ.Name(), typ.Field(i).Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func decodeValue(data json.RawMessage, v reflect.Value) error {
+	var isNull = bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+
+	switch v.Kind() {
+	case reflect.Interface:
+		var node, err = UnmarshalNode(data)
+		if err != nil {
+			return err
+		}
+		if node == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if !reflect.TypeOf(node).Implements(v.Type()) {
+			return fmt.Errorf("ast: %T does not implement %s", node, v.Type())
+		}
+		v.Set(reflect.ValueOf(node))
+		return nil
+	case reflect.Pointer:
+		if isNull {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		var elem = reflect.New(v.Type().Elem())
+		if err := decodeValue(data, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Struct:
+		return decodeStruct(data, v)
+	case reflect.Slice:
+		if isNull {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		var slice = reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeValue(item, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.Map:
+		if isNull {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(data, &members); err != nil {
+			return err
+		}
+		if !reflect.PointerTo(v.Type().Key()).Implements(textUnmarshalerType) {
+			return fmt.Errorf("ast: unsupported map key %s", v.Type().Key())
+		}
+		var m = reflect.MakeMapWithSize(v.Type(), len(members))
+		for name, raw := range members {
+			var key = reflect.New(v.Type().Key())
+			if err := key.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(name)); err != nil {
+				return err
+			}
+			var value = reflect.New(v.Type().Elem()).Elem()
+			if err := decodeValue(raw, value); err != nil {
+				return err
+			}
+			m.SetMapIndex(key.Elem(), value)
+		}
+		v.Set(m)
+		return nil
+	default:
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+}
+
+// sortKeys sorts the keys of a CommentMap by transform, then by nested
+// transform and by item.
+func sortKeys(keys []string) {
+	sort.Slice(keys, func(i, j int) bool {
+		var a, b NodePath
+		if a.UnmarshalText([]byte(keys[i])) != nil || b.UnmarshalText([]byte(keys[j])) != nil {
+			return keys[i] < keys[j]
+		}
+		if a.Transform != b.Transform {
+			return a.Transform < b.Transform
+		}
+		if a.Nested != b.Nested {
+			return a.Nested < b.Nested
+		}
+		return a.Item < b.Item
+	})
+}
+
+// MarshalText encodes the path as "T" for transform T, "T.I" for item I of
+// transform T, or "T:N" for the nested transform N of transform T. The query
+// header is "-1".
+func (path NodePath) MarshalText() ([]byte, error) {
+	if path.Nested > 0 {
+		return []byte(fmt.Sprintf("%d:%d", path.Transform, path.Nested)), nil
+	}
+	if path.Item == -1 {
+		return []byte(strconv.Itoa(path.Transform)), nil
+	}
+	return []byte(fmt.Sprintf("%d.%d", path.Transform, path.Item)), nil
+}
+
+func (path *NodePath) UnmarshalText(text []byte) error {
+	if transform, nested, ok := strings.Cut(string(text), ":"); ok {
+		var t, err = strconv.Atoi(transform)
+		var n, nErr = strconv.Atoi(nested)
+		if err != nil || nErr != nil || n < 1 {
+			return fmt.Errorf("ast: bad node path %q", text)
+		}
+		*path = NodePath{Transform: t, Item: -1, Nested: n}
+		return nil
+	}
+	var transform, item, hasItem = strings.Cut(string(text), ".")
+	var t, err = strconv.Atoi(transform)
+	if err != nil {
+		return fmt.Errorf("ast: bad node path %q", text)
+	}
+	var i = -1
+	if hasItem {
+		if i, err = strconv.Atoi(item); err != nil || i < 0 {
+			return fmt.Errorf("ast: bad node path %q", text)
+		}
+	}
+	*path = NodePath{Transform: t, Item: i}
+	return nil
+}
+
+func (n FromTransform) MarshalJSON() ([]byte, error)   { return marshalNode(n) }
+func (n SelectTransform) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+func (n DeriveTransform) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+func (n FilterTransform) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+func (n SortTransform) MarshalJSON() ([]byte, error)   { return marshalNode(n) }
+func (n TakeTransform) MarshalJSON() ([]byte, error)   { return marshalNode(n) }
+func (n WindowTransform) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+func (n GroupTransform) MarshalJSON() ([]byte, error)  { return marshalNode(n) }
+func (n CustomTransform) MarshalJSON() ([]byte, error) { return marshalNode(n) }
+func (n QueryHeader) MarshalJSON() ([]byte, error)     { return marshalNode(n) }
+func (n ExprList) MarshalJSON() ([]byte, error)        { return marshalNode(n) }
+func (n Root) MarshalJSON() ([]byte, error)            { return marshalNode(n) }
+func (n Column) MarshalJSON() ([]byte, error)          { return marshalNode(n) }
+func (n Integer) MarshalJSON() ([]byte, error)         { return marshalNode(n) }
+func (n Date) MarshalJSON() ([]byte, error)            { return marshalNode(n) }
+func (n Time) MarshalJSON() ([]byte, error)            { return marshalNode(n) }
+func (n Timestamp) MarshalJSON() ([]byte, error)       { return marshalNode(n) }
+func (n Interval) MarshalJSON() ([]byte, error)        { return marshalNode(n) }
+func (n String) MarshalJSON() ([]byte, error)          { return marshalNode(n) }
+func (n Float) MarshalJSON() ([]byte, error)           { return marshalNode(n) }
+func (n Boolean) MarshalJSON() ([]byte, error)         { return marshalNode(n) }
+func (n BinaryExpr) MarshalJSON() ([]byte, error)      { return marshalNode(n) }
+func (n UnaryExpr) MarshalJSON() ([]byte, error)       { return marshalNode(n) }
+func (n ParenExpr) MarshalJSON() ([]byte, error)       { return marshalNode(n) }
+func (n AssignExpr) MarshalJSON() ([]byte, error)      { return marshalNode(n) }
+func (n CallExpr) MarshalJSON() ([]byte, error)        { return marshalNode(n) }
+func (n RangeExpr) MarshalJSON() ([]byte, error)       { return marshalNode(n) }
+func (n CaseExpr) MarshalJSON() ([]byte, error)        { return marshalNode(n) }
+func (n Array) MarshalJSON() ([]byte, error)           { return marshalNode(n) }
+func (n PipeExpr) MarshalJSON() ([]byte, error)        { return marshalNode(n) }
+func (n FuncLit) MarshalJSON() ([]byte, error)         { return marshalNode(n) }
+
+func (n *FromTransform) UnmarshalJSON(b []byte) error   { return unmarshalNode(b, n) }
+func (n *SelectTransform) UnmarshalJSON(b []byte) error { return unmarshalNode(b, n) }
+func (n *DeriveTransform) UnmarshalJSON(b []byte) error { return unmarshalNode(b, n) }
+func (n *FilterTransform) UnmarshalJSON(b []byte) error { return unmarshalNode(b, n) }
+func (n *SortTransform) UnmarshalJSON(b []byte) error   { return unmarshalNode(b, n) }
+func (n *TakeTransform) UnmarshalJSON(b []byte) error   { return unmarshalNode(b, n) }
+func (n *WindowTransform) UnmarshalJSON(b []byte) error { return unmarshalNode(b, n) }
+func (n *GroupTransform) UnmarshalJSON(b []byte) error  { return unmarshalNode(b, n) }
+func (n *CustomTransform) UnmarshalJSON(b []byte) error { return unmarshalNode(b, n) }
+func (n *QueryHeader) UnmarshalJSON(b []byte) error     { return unmarshalNode(b, n) }
+func (n *ExprList) UnmarshalJSON(b []byte) error        { return unmarshalNode(b, n) }
+func (n *Root) UnmarshalJSON(b []byte) error            { return unmarshalNode(b, n) }
+func (n *Column) UnmarshalJSON(b []byte) error          { return unmarshalNode(b, n) }
+func (n *Integer) UnmarshalJSON(b []byte) error         { return unmarshalNode(b, n) }
+func (n *Date) UnmarshalJSON(b []byte) error            { return unmarshalNode(b, n) }
+func (n *Time) UnmarshalJSON(b []byte) error            { return unmarshalNode(b, n) }
+func (n *Timestamp) UnmarshalJSON(b []byte) error       { return unmarshalNode(b, n) }
+func (n *Interval) UnmarshalJSON(b []byte) error        { return unmarshalNode(b, n) }
+func (n *String) UnmarshalJSON(b []byte) error          { return unmarshalNode(b, n) }
+func (n *Float) UnmarshalJSON(b []byte) error           { return unmarshalNode(b, n) }
+func (n *Boolean) UnmarshalJSON(b []byte) error         { return unmarshalNode(b, n) }
+func (n *BinaryExpr) UnmarshalJSON(b []byte) error      { return unmarshalNode(b, n) }
+func (n *UnaryExpr) UnmarshalJSON(b []byte) error       { return unmarshalNode(b, n) }
+func (n *ParenExpr) UnmarshalJSON(b []byte) error       { return unmarshalNode(b, n) }
+func (n *AssignExpr) UnmarshalJSON(b []byte) error      { return unmarshalNode(b, n) }
+func (n *CallExpr) UnmarshalJSON(b []byte) error        { return unmarshalNode(b, n) }
+func (n *RangeExpr) UnmarshalJSON(b []byte) error       { return unmarshalNode(b, n) }
+func (n *CaseExpr) UnmarshalJSON(b []byte) error        { return unmarshalNode(b, n) }
+func (n *Array) UnmarshalJSON(b []byte) error           { return unmarshalNode(b, n) }
+func (n *PipeExpr) UnmarshalJSON(b []byte) error        { return unmarshalNode(b, n) }
+func (n *FuncLit) UnmarshalJSON(b []byte) error         { return unmarshalNode(b, n) }
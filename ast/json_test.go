@@ -0,0 +1,121 @@
+package ast_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/token"
+)
+
+func TestJSON(tt *testing.T) {
+	var testCases = []struct {
+		src  string
+		want string
+	}{
+		{
+			src:  `from e1 = table1`,
+			want: `{"kind":"Root","header":null,"transforms":[{"kind":"FromTransform","alias":{"name":"e1","pos":5},"table":{"name":"table1","pos":10}}],"comments":null}`,
+		},
+		{
+			src:  `derive x = -(1 + y) # comment`,
+			want: `{"kind":"Root","header":null,"transforms":[{"kind":"DeriveTransform","list":{"kind":"ExprList","items":[{"kind":"AssignExpr","name":"x","expr":{"kind":"UnaryExpr","x":{"kind":"ParenExpr","x":{"kind":"BinaryExpr","x":{"kind":"Integer","value":1},"y":{"kind":"Column","name":{"name":"y","pos":17}},"op":"ADD","opPos":15}},"op":"SUB"}}],"braces":false}}],"comments":{"0":{"leading":null,"trailing":{"list":[{"text":"# comment","pos":20}]}}}}`,
+		},
+		{
+			src: `
+			select [
+			  column1, # comment
+			  123, 1.5, "hello world",
+			  @2022-12-31, @01:02:03, @2022-12-31T01:02:03, 123seconds,
+			  1 * 2 + 3,
+			]`,
+		},
+		{
+			src: `
+			prql dialect:sqlite # comment
+			derive x = true
+			take 10`,
+		},
+		{
+			src: `filter (a | in [1, 2]) and (b | round 2 | in 1..) == {c, d}`,
+		},
+		{
+			src: `
+			group a (
+			  take 1 # comment
+			  # end
+			)`,
+		},
+		{
+			src: `derive [f = x -> x + 1, g = (a b -> a * b)]`,
+		},
+	}
+
+	for _, tc := range testCases {
+		var p = parser.NewParser()
+		p.SetParseComments(true)
+		var root, err = p.Parse(strings.NewReader(tc.src))
+		if err != nil {
+			tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", tc.src, err)
+		}
+
+		var got, marshalErr = json.Marshal(root)
+		if marshalErr != nil {
+			tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", tc.src, marshalErr)
+		}
+		if tc.want != "" {
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				tt.Fatalf("mismatching results\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.src, diff)
+			}
+		}
+
+		var decoded ast.Root
+		if err := json.Unmarshal(got, &decoded); err != nil {
+			tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", tc.src, err)
+		}
+		if diff := cmp.Diff(root, &decoded); diff != "" {
+			tt.Fatalf("mismatching round trip\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.src, diff)
+		}
+	}
+}
+
+func TestUnmarshalNode(tt *testing.T) {
+	var node, err = ast.UnmarshalNode([]byte(`{"kind":"BinaryExpr","x":{"kind":"Integer","value":1},"y":{"kind":"Float","value":2.5},"op":"MUL"}`))
+	if err != nil {
+		tt.Fatal(err)
+	}
+	var want ast.Node = ast.BinaryExpr{X: ast.Integer{Value: 1}, Y: ast.Float{Value: 2.5}, Op: token.MUL}
+	if diff := cmp.Diff(want, node); diff != "" {
+		tt.Fatalf("mismatching results\ndiff guide:\n  - want\n  + got\ndiff:\n%s", diff)
+	}
+
+	var errTestCases = []struct {
+		src  string
+		want string
+	}{
+		{
+			src:  `{"kind":"Unknown"}`,
+			want: `ast: unknown node kind "Unknown"`,
+		},
+		{
+			src:  `{"kind":"SelectTransform","list":{"kind":"ExprList","items":[{"kind":"FromTransform"}]}}`,
+			want: `SelectTransform.List: ExprList.Items: ast: ast.FromTransform does not implement ast.Expr`,
+		},
+		{
+			src:  `{"kind":"BinaryExpr","op":"PLUS"}`,
+			want: `BinaryExpr.Op: unknown token "PLUS"`,
+		},
+	}
+	for _, tc := range errTestCases {
+		var _, err = ast.UnmarshalNode([]byte(tc.src))
+		if err == nil {
+			tt.Fatalf("expected an error, got nil\nsrc:\n%s", tc.src)
+		}
+		if diff := cmp.Diff(tc.want, err.Error()); diff != "" {
+			tt.Fatalf("mismatching errors\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.src, diff)
+		}
+	}
+}
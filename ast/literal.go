@@ -0,0 +1,57 @@
+package ast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// InterpolationPart is a part of the value of an f"..." or s"..." string:
+// either literal text, or the source of the expression between braces.
+type InterpolationPart struct {
+	Text string
+	Expr string
+}
+
+// Unquote returns the prefix of the string literal ('f', 's' or 0) and its
+// value without quotes and with escape sequences resolved.
+func (s String) Unquote() (byte, string, error) {
+	var prefix byte
+	var lit = s.Value
+	if strings.HasPrefix(lit, "f") || strings.HasPrefix(lit, "s") {
+		prefix, lit = lit[0], lit[1:]
+	}
+
+	if len(lit) >= 2 && lit[0] == '\'' && lit[len(lit)-1] == '\'' {
+		var body = strings.ReplaceAll(lit[1:len(lit)-1], `\'`, `'`)
+		lit = `"` + strings.ReplaceAll(body, `"`, `\"`) + `"`
+	}
+	var value, err = strconv.Unquote(lit)
+	if err != nil {
+		return 0, "", fmt.Errorf("bad string literal %s: %v", s.Value, err)
+	}
+	return prefix, value, nil
+}
+
+// Interpolate splits the value of an f"..." or s"..." string into text and
+// the expressions between braces.
+func Interpolate(value string) ([]InterpolationPart, error) {
+	var parts []InterpolationPart
+	for value != "" {
+		var open = strings.Index(value, "{")
+		if open == -1 {
+			parts = append(parts, InterpolationPart{Text: value})
+			break
+		}
+		var end = strings.Index(value[open:], "}")
+		if end == -1 {
+			return nil, fmt.Errorf("unclosed interpolation in %q", value)
+		}
+		if open > 0 {
+			parts = append(parts, InterpolationPart{Text: value[:open]})
+		}
+		parts = append(parts, InterpolationPart{Expr: strings.TrimSpace(value[open+1 : open+end])})
+		value = value[open+end+1:]
+	}
+	return parts, nil
+}
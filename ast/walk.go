@@ -0,0 +1,93 @@
+package ast
+
+// Inspect traverses the tree rooted at node in depth-first order, calling f
+// for each node. If f returns false, the children of the node are skipped.
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Root:
+		Inspect(*n, f)
+	case Root:
+		if n.Header != nil {
+			Inspect(*n.Header, f)
+		}
+		for _, t := range n.Transforms {
+			Inspect(t, f)
+		}
+	case QueryHeader:
+		for _, arg := range n.Args {
+			Inspect(arg.Value, f)
+		}
+	case FromTransform:
+	case SelectTransform:
+		Inspect(n.List, f)
+	case DeriveTransform:
+		Inspect(n.List, f)
+	case SortTransform:
+		Inspect(n.List, f)
+	case FilterTransform:
+		Inspect(n.Expr, f)
+	case TakeTransform:
+		Inspect(n.Expr, f)
+	case WindowTransform:
+		for _, arg := range n.Named {
+			Inspect(arg.Value, f)
+		}
+		for _, t := range n.Pipeline {
+			Inspect(t, f)
+		}
+	case GroupTransform:
+		Inspect(n.By, f)
+		for _, t := range n.Pipeline {
+			Inspect(t, f)
+		}
+	case CustomTransform:
+		Inspect(n.List, f)
+	case ExprList:
+		for _, item := range n.Items {
+			Inspect(item, f)
+		}
+	case BinaryExpr:
+		Inspect(n.X, f)
+		Inspect(n.Y, f)
+	case UnaryExpr:
+		Inspect(n.X, f)
+	case ParenExpr:
+		Inspect(n.X, f)
+	case AssignExpr:
+		Inspect(n.Expr, f)
+	case RangeExpr:
+		if n.Start != nil {
+			Inspect(n.Start, f)
+		}
+		if n.End != nil {
+			Inspect(n.End, f)
+		}
+	case Array:
+		for _, item := range n.Items {
+			Inspect(item, f)
+		}
+	case FuncLit:
+		Inspect(n.Body, f)
+	case PipeExpr:
+		Inspect(n.X, f)
+		for _, call := range n.Calls {
+			Inspect(call, f)
+		}
+	case CaseExpr:
+		for _, arm := range n.Arms {
+			Inspect(arm.Cond, f)
+			Inspect(arm.Value, f)
+		}
+	case CallExpr:
+		for _, arg := range n.Named {
+			Inspect(arg.Value, f)
+		}
+		for _, arg := range n.Args {
+			Inspect(arg, f)
+		}
+	}
+}
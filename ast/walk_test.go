@@ -0,0 +1,39 @@
+package ast_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/token"
+)
+
+func TestInspect(tt *testing.T) {
+	var root, err = parser.NewParser().Parse(strings.NewReader("prql dialect:sqlite\nfrom t\nderive [x = -(a + 1)]\nfilter x > 2\ntake 1"))
+	if err != nil {
+		tt.Fatal(err)
+	}
+
+	var got []string
+	ast.Inspect(root, func(n ast.Node) bool {
+		got = append(got, fmt.Sprintf("%T", n))
+		// skip the operands of comparisons
+		var binary, ok = n.(ast.BinaryExpr)
+		return !ok || binary.Op != token.GTR
+	})
+
+	var want = []string{
+		"*ast.Root", "ast.Root",
+		"ast.QueryHeader", "ast.Column",
+		"ast.FromTransform",
+		"ast.DeriveTransform", "ast.ExprList", "ast.AssignExpr", "ast.UnaryExpr", "ast.ParenExpr", "ast.BinaryExpr", "ast.Column", "ast.Integer",
+		"ast.FilterTransform", "ast.BinaryExpr",
+		"ast.TakeTransform", "ast.Integer",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		tt.Fatalf("mismatching results\ndiff guide:\n  - want\n  + got\ndiff:\n%s", diff)
+	}
+}
@@ -0,0 +1,145 @@
+// Package catalog loads the table definitions that queries are checked
+// against.
+package catalog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/siadat/prql-parser/resolver"
+)
+
+// Catalog is a set of tables, e.g.
+//
+//	{"tables": [{"name": "employees", "columns": [{"name": "id", "type": "int"}]}]}
+type Catalog struct {
+	Tables []Table `json:"tables"`
+}
+
+type Table struct {
+	Name    string   `json:"name"`
+	Columns []Column `json:"columns"`
+}
+
+// Column is a column of a table. Type is the SQL type as written in the
+// definition, in lower case, and may be empty.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// Load reads a catalog from a file. Files ending in .json, .yaml or .yml and
+// .sql are read with ParseJSON, ParseYAML and ParseDDL. YAML files must be
+// in the block style subset that ParseYAML accepts.
+func Load(path string) (*Catalog, error) {
+	var data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c *Catalog
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		c, err = ParseJSON(data)
+	case ".yaml", ".yml":
+		c, err = ParseYAML(data)
+	case ".sql":
+		c, err = ParseDDL(data)
+	default:
+		return nil, fmt.Errorf("catalog: %s: unknown file extension %q", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
+
+// ParseJSON parses a catalog in the JSON format shown on Catalog.
+func ParseJSON(data []byte) (*Catalog, error) {
+	var c Catalog
+	var dec = json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("catalog: %v", err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ParseYAML parses a catalog in the YAML equivalent of the JSON format, e.g.
+//
+//	tables:
+//	  - name: employees
+//	    columns:
+//	      - name: id
+//	        type: int
+//
+// Only a subset of YAML is supported: block mappings with plain keys, block
+// sequences, plain and quoted scalars, [] and comments. Flow collections,
+// quoted keys, anchors, aliases, tags, block scalars and multiple documents
+// are reported as unsupported YAML constructs with their line number.
+func ParseYAML(data []byte) (*Catalog, error) {
+	var v, err = parseYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("catalog: %v", err)
+	}
+	var b, marshalErr = json.Marshal(v)
+	if marshalErr != nil {
+		return nil, fmt.Errorf("catalog: %v", marshalErr)
+	}
+	return ParseJSON(b)
+}
+
+func (c *Catalog) validate() error {
+	var tables = map[string]bool{}
+	for _, t := range c.Tables {
+		if t.Name == "" {
+			return fmt.Errorf("catalog: table without a name")
+		}
+		if tables[t.Name] {
+			return fmt.Errorf("catalog: duplicate table %s", t.Name)
+		}
+		tables[t.Name] = true
+
+		var columns = map[string]bool{}
+		for _, col := range t.Columns {
+			if col.Name == "" {
+				return fmt.Errorf("catalog: column without a name in table %s", t.Name)
+			}
+			if columns[col.Name] {
+				return fmt.Errorf("catalog: duplicate column %s in table %s", col.Name, t.Name)
+			}
+			columns[col.Name] = true
+		}
+	}
+	return nil
+}
+
+// Table returns the table with the given name.
+func (c *Catalog) Table(name string) (*Table, bool) {
+	for i := range c.Tables {
+		if c.Tables[i].Name == name {
+			return &c.Tables[i], true
+		}
+	}
+	return nil, false
+}
+
+// Schema returns the column names of the tables, for resolver.Resolve.
+func (c *Catalog) Schema() resolver.Schema {
+	var schema = resolver.Schema{}
+	for _, t := range c.Tables {
+		var names = make([]string, len(t.Columns))
+		for i, col := range t.Columns {
+			names[i] = col.Name
+		}
+		schema[t.Name] = names
+	}
+	return schema
+}
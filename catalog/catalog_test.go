@@ -0,0 +1,128 @@
+package catalog_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/catalog"
+)
+
+func TestLoad(tt *testing.T) {
+	var want = &catalog.Catalog{
+		Tables: []catalog.Table{
+			{
+				Name: "employees",
+				Columns: []catalog.Column{
+					{Name: "id", Type: "integer"},
+					{Name: "name", Type: "varchar(100)"},
+					{Name: "salary", Type: "numeric(10,2)"},
+					{Name: "hired_at", Type: "timestamp with time zone"},
+				},
+			},
+			{
+				Name: "orders",
+				Columns: []catalog.Column{
+					{Name: "order id", Type: "bigint"},
+					{Name: "tags", Type: "text[]"},
+				},
+			},
+		},
+	}
+
+	for _, path := range []string{"testdata/shop.json", "testdata/shop.yaml", "testdata/shop.sql"} {
+		var got, err = catalog.Load(path)
+		if err != nil {
+			tt.Fatalf("test case failed\npath: %s\nerr: %v", path, err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			tt.Fatalf("mismatching results\npath: %s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", path, diff)
+		}
+	}
+}
+
+func TestErrors(tt *testing.T) {
+	var testCases = []struct {
+		parse func([]byte) (*catalog.Catalog, error)
+		src   string
+		want  string
+	}{
+		{
+			parse: catalog.ParseJSON,
+			src:   `{"tables": [{"name": "t", "cols": []}]}`,
+			want:  `catalog: json: unknown field "cols"`,
+		},
+		{
+			parse: catalog.ParseJSON,
+			src:   `{"tables": [{"name": "t"}, {"name": "t"}]}`,
+			want:  `catalog: duplicate table t`,
+		},
+		{
+			parse: catalog.ParseYAML,
+			src:   "tables:\n- name: t\n  columns:\n  - name: a\n  - name: a\n",
+			want:  `catalog: duplicate column a in table t`,
+		},
+		{
+			parse: catalog.ParseYAML,
+			src:   "tables:\n- {name: t}\n",
+			want:  `catalog: line 2: unsupported YAML construct: flow mapping {name: t}`,
+		},
+		{
+			parse: catalog.ParseYAML,
+			src:   "tables:\n- name: t\n  columns: [{name: a}]\n",
+			want:  `catalog: line 3: unsupported YAML construct: flow sequence [{name: a}]`,
+		},
+		{
+			parse: catalog.ParseYAML,
+			src:   "tables:\n- \"name\": t\n",
+			want:  `catalog: line 2: unsupported YAML construct: quoted key "name": t`,
+		},
+		{
+			parse: catalog.ParseYAML,
+			src:   "tables:\n- name: t\n  columns: &cols\n  - name: a\n- name: u\n  columns: *cols\n",
+			want:  `catalog: line 3: unsupported YAML construct: anchor &cols`,
+		},
+		{
+			parse: catalog.ParseYAML,
+			src:   "tables:\n- name: t\n---\ntables: []\n",
+			want:  `catalog: line 3: unsupported YAML construct: multiple documents ---`,
+		},
+		{
+			parse: catalog.ParseYAML,
+			src:   "tables:\n  - name: t\n   columns: []\n",
+			want:  `catalog: line 3: unexpected indentation`,
+		},
+		{
+			parse: catalog.ParseDDL,
+			src:   "create table t (a int, b varchar(10)",
+			want:  `catalog: unexpected end of file`,
+		},
+		{
+			parse: catalog.ParseDDL,
+			src:   "create table t (a int,\n, b int)",
+			want:  `catalog: line 2: unexpected ","`,
+		},
+	}
+
+	for _, tc := range testCases {
+		var _, err = tc.parse([]byte(tc.src))
+		var got = "<nil>"
+		if err != nil {
+			got = err.Error()
+		}
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			tt.Fatalf("mismatching results\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.src, diff)
+		}
+	}
+}
+
+func TestSchema(tt *testing.T) {
+	var c, err = catalog.ParseDDL([]byte("create table a (x int, y int); create table b (z text);"))
+	if err != nil {
+		tt.Fatal(err)
+	}
+	var got = c.Schema()
+	var want = map[string][]string{"a": {"x", "y"}, "b": {"z"}}
+	if diff := cmp.Diff(want, map[string][]string(got)); diff != "" {
+		tt.Fatalf("mismatching results\ndiff guide:\n  - want\n  + got\ndiff:\n%s", diff)
+	}
+}
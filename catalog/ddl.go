@@ -0,0 +1,340 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ParseDDL parses the CREATE TABLE statements of a SQL script. Other
+// statements are skipped, and so are the table constraints and the column
+// constraints of the definitions. Names qualified by a schema are reduced to
+// the table name.
+func ParseDDL(data []byte) (*Catalog, error) {
+	var tokens, err = sqlTokens(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("catalog: %v", err)
+	}
+
+	var p = &ddlParser{tokens: tokens}
+	var c = &Catalog{}
+	for p.i < len(p.tokens) {
+		if !p.keywords("create", "table") && !p.keywords("create", "temporary", "table") && !p.keywords("create", "temp", "table") {
+			p.skipStatement()
+			continue
+		}
+		var t, err = p.createTable()
+		if err != nil {
+			return nil, fmt.Errorf("catalog: %v", err)
+		}
+		c.Tables = append(c.Tables, t)
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+type sqlToken struct {
+	text   string
+	quoted bool // a quoted identifier
+	line   int
+}
+
+type ddlParser struct {
+	tokens []sqlToken
+	i      int
+}
+
+// sqlTokens splits src into words, quoted identifiers, string literals and
+// punctuation, skipping spaces and comments.
+func sqlTokens(src string) ([]sqlToken, error) {
+	var tokens []sqlToken
+	var line = 1
+	for i := 0; i < len(src); {
+		var c = src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "--"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			var end = strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case strings.HasPrefix(src[i:], "[]"):
+			// array types, e.g. int[]
+			tokens = append(tokens, sqlToken{text: "[]", line: line})
+			i += 2
+		case c == '"' || c == '`' || c == '[' || c == '\'':
+			var closer = c
+			if c == '[' {
+				closer = ']'
+			}
+			var j = i + 1
+			for ; j < len(src); j++ {
+				if src[j] == closer {
+					// doubled quotes are escaped quotes
+					if j+1 < len(src) && src[j+1] == closer && closer != ']' {
+						j++
+						continue
+					}
+					break
+				}
+			}
+			if j == len(src) {
+				return nil, fmt.Errorf("line %d: unterminated %c", line, c)
+			}
+			var text = src[i+1 : j]
+			text = strings.ReplaceAll(text, string([]byte{closer, closer}), string(closer))
+			if c == '\'' {
+				// string literals only appear in defaults and checks,
+				// which are skipped
+				text = "'" + text + "'"
+			}
+			tokens = append(tokens, sqlToken{text: text, quoted: c != '\'', line: line})
+			line += strings.Count(src[i:j], "\n")
+			i = j + 1
+		case isWordByte(c):
+			var j = i
+			for j < len(src) && isWordByte(src[j]) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: src[i:j], line: line})
+			i = j
+		default:
+			tokens = append(tokens, sqlToken{text: src[i : i+1], line: line})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+func (p *ddlParser) peek() sqlToken {
+	if p.i < len(p.tokens) {
+		return p.tokens[p.i]
+	}
+	return sqlToken{}
+}
+
+// is reports whether the current token is the unquoted keyword kw.
+func (p *ddlParser) is(kw string) bool {
+	var t = p.peek()
+	return !t.quoted && strings.EqualFold(t.text, kw)
+}
+
+// keywords consumes kws if they are the next tokens.
+func (p *ddlParser) keywords(kws ...string) bool {
+	for j, kw := range kws {
+		if p.i+j >= len(p.tokens) {
+			return false
+		}
+		var t = p.tokens[p.i+j]
+		if t.quoted || !strings.EqualFold(t.text, kw) {
+			return false
+		}
+	}
+	p.i += len(kws)
+	return true
+}
+
+func (p *ddlParser) skipStatement() {
+	for p.i < len(p.tokens) {
+		var t = p.tokens[p.i]
+		p.i++
+		if t.text == ";" && !t.quoted {
+			return
+		}
+	}
+}
+
+func (p *ddlParser) expect(text string) error {
+	var t = p.peek()
+	if t.quoted || t.text != text {
+		return p.unexpected()
+	}
+	p.i++
+	return nil
+}
+
+func (p *ddlParser) unexpected() error {
+	if p.i >= len(p.tokens) {
+		return fmt.Errorf("unexpected end of file")
+	}
+	var t = p.tokens[p.i]
+	return fmt.Errorf("line %d: unexpected %q", t.line, t.text)
+}
+
+func (p *ddlParser) name() (string, error) {
+	var t = p.peek()
+	if t.text == "" || (!t.quoted && !isWordByte(t.text[0])) {
+		return "", p.unexpected()
+	}
+	p.i++
+	return t.text, nil
+}
+
+// qualifiedName returns the last part of a name like schema.table.
+func (p *ddlParser) qualifiedName() (string, error) {
+	var name, err = p.name()
+	for err == nil && p.peek().text == "." && !p.peek().quoted {
+		p.i++
+		name, err = p.name()
+	}
+	return name, err
+}
+
+func (p *ddlParser) createTable() (Table, error) {
+	p.keywords("if", "not", "exists")
+	var name, err = p.qualifiedName()
+	if err != nil {
+		return Table{}, err
+	}
+	var t = Table{Name: name}
+	if err := p.expect("("); err != nil {
+		return Table{}, err
+	}
+
+	for {
+		if p.isTableConstraint() {
+			p.skipDefinition()
+		} else {
+			var col, err = p.column()
+			if err != nil {
+				return Table{}, err
+			}
+			t.Columns = append(t.Columns, col)
+		}
+
+		var next = p.peek()
+		if next.text == ")" && !next.quoted {
+			break
+		}
+		if err := p.expect(","); err != nil {
+			return Table{}, err
+		}
+	}
+	p.skipStatement()
+	return t, nil
+}
+
+var tableConstraints = []string{"constraint", "primary", "unique", "foreign", "check", "key", "index", "exclude"}
+
+func (p *ddlParser) isTableConstraint() bool {
+	for _, kw := range tableConstraints {
+		if p.is(kw) {
+			return true
+		}
+	}
+	return false
+}
+
+// columnConstraints end the type of a column definition.
+var columnConstraints = []string{
+	"not", "null", "primary", "unique", "default", "references", "check",
+	"constraint", "collate", "generated", "auto_increment", "autoincrement",
+	"identity", "comment", "on",
+}
+
+func (p *ddlParser) column() (Column, error) {
+	var name, err = p.name()
+	if err != nil {
+		return Column{}, err
+	}
+	var col = Column{Name: name}
+
+	// the type is the words and parenthesized arguments before the
+	// constraints, e.g. double precision or varchar(20)
+	var parts []string
+	for p.i < len(p.tokens) {
+		var t = p.peek()
+		if !t.quoted && (t.text == "," || t.text == ")") {
+			break
+		}
+		var constraint = false
+		for _, kw := range columnConstraints {
+			if p.is(kw) {
+				constraint = true
+			}
+		}
+		if constraint {
+			break
+		}
+		if len(parts) > 0 && !t.quoted && (t.text == "(" || t.text == "[]") {
+			if t.text == "[]" {
+				p.i++
+				parts[len(parts)-1] += t.text
+				continue
+			}
+			var start = p.i
+			if !p.skipParens() {
+				return Column{}, p.unexpected()
+			}
+			var args []string
+			for _, arg := range p.tokens[start+1 : p.i-1] {
+				args = append(args, arg.text)
+			}
+			parts[len(parts)-1] += "(" + strings.ReplaceAll(strings.Join(args, " "), " , ", ",") + ")"
+			continue
+		}
+		parts = append(parts, strings.ToLower(t.text))
+		p.i++
+	}
+	col.Type = strings.Join(parts, " ")
+
+	p.skipDefinition()
+	return col, nil
+}
+
+// skipParens skips a parenthesized list of tokens, starting at "(", and
+// reports whether the list is closed.
+func (p *ddlParser) skipParens() bool {
+	var depth = 0
+	for p.i < len(p.tokens) {
+		var t = p.tokens[p.i]
+		p.i++
+		if t.quoted {
+			continue
+		}
+		switch t.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// skipDefinition skips tokens up to the "," or ")" that ends a column or
+// constraint definition.
+func (p *ddlParser) skipDefinition() {
+	for p.i < len(p.tokens) {
+		var t = p.peek()
+		if !t.quoted {
+			switch t.text {
+			case ",", ")":
+				return
+			case "(":
+				p.skipParens()
+				continue
+			}
+		}
+		p.i++
+	}
+}
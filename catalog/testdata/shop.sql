@@ -0,0 +1,17 @@
+-- the tables of the shop database
+CREATE TABLE IF NOT EXISTS public.employees (
+    id INTEGER PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    salary NUMERIC(10, 2) DEFAULT 0 CHECK (salary >= 0),
+    hired_at TIMESTAMP WITH TIME ZONE,
+    /* a table constraint */
+    CONSTRAINT employees_name_key UNIQUE (name)
+);
+
+CREATE INDEX employees_name ON employees (name);
+
+create table "orders" (
+    "order id" bigint references employees(id),
+    tags text[],
+    primary key ("order id")
+);
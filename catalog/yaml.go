@@ -0,0 +1,265 @@
+package catalog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a line of a YAML document without its indentation, comments
+// and trailing spaces.
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	i     int
+}
+
+// parseYAML parses the subset of YAML used by catalogs: block mappings with
+// plain keys, block sequences, plain and quoted scalars, empty flow sequences
+// and comments. Mappings are returned as map[string]interface{}, sequences as
+// []interface{} and scalars as strings. Other constructs are reported with
+// their line number.
+func parseYAML(data []byte) (interface{}, error) {
+	var p = &yamlParser{}
+	for i, text := range strings.Split(string(data), "\n") {
+		text = strings.TrimRight(stripComment(text), " \t\r")
+		var trimmed = strings.TrimLeft(text, " ")
+		if trimmed == "" || trimmed == "---" && len(p.lines) == 0 {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed in indentation", i+1)
+		}
+		var line = yamlLine{num: i + 1, indent: len(text) - len(trimmed), text: trimmed}
+		if err := checkConstructs(line); err != nil {
+			return nil, err
+		}
+		p.lines = append(p.lines, line)
+	}
+	if len(p.lines) == 0 {
+		return nil, nil
+	}
+
+	var v, err = p.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.i].num)
+	}
+	return v, nil
+}
+
+// stripComment removes a comment that starts at the beginning of the line or
+// after a space, outside quotes.
+func stripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// yamlConstructs names the constructs outside the subset by the character
+// they start with.
+var yamlConstructs = map[byte]string{
+	'[': "flow sequence",
+	'{': "flow mapping",
+	'&': "anchor",
+	'*': "alias",
+	'!': "tag",
+	'|': "block scalar",
+	'>': "block scalar",
+	'?': "complex key",
+	'%': "directive",
+	'@': "reserved indicator",
+	'`': "reserved indicator",
+}
+
+// checkConstructs returns an error for the first construct of line outside
+// the subset: the item of a sequence, the key of a mapping or a value.
+func checkConstructs(line yamlLine) error {
+	var text = line.text
+	var unsupported = func(what string) error {
+		return fmt.Errorf("line %d: unsupported YAML construct: %s %s", line.num, what, text)
+	}
+	if text == "---" || text == "..." || strings.HasPrefix(text, "--- ") {
+		return unsupported("multiple documents")
+	}
+	for isSequenceItem(text) {
+		text = strings.TrimLeft(text[1:], " ")
+	}
+	if isQuotedKey(text) {
+		return unsupported("quoted key")
+	}
+	if key, rest, ok := splitKey(text); ok {
+		if key == "<<" {
+			return unsupported("merge key")
+		}
+		text = rest
+	}
+	if text == "" || text == "[]" {
+		return nil
+	}
+	if what, ok := yamlConstructs[text[0]]; ok {
+		return unsupported(what)
+	}
+	return nil
+}
+
+// isQuotedKey reports whether text starts with a quoted key, e.g. "id": 1.
+func isQuotedKey(text string) bool {
+	if text == "" || text[0] != '"' && text[0] != '\'' {
+		return false
+	}
+	var end = strings.IndexByte(text[1:], text[0])
+	if end < 0 {
+		return false
+	}
+	var rest = text[end+2:]
+	return rest == ":" || strings.HasPrefix(rest, ": ")
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// value parses the block that starts at the current line, whose indentation
+// must be at least indent.
+func (p *yamlParser) value(indent int) (interface{}, error) {
+	var line = p.lines[p.i]
+	if line.indent < indent {
+		return nil, nil
+	}
+	if isSequenceItem(line.text) {
+		return p.sequence(line.indent)
+	}
+	if _, _, ok := splitKey(line.text); ok {
+		return p.mapping(line.indent)
+	}
+	p.i++
+	return scalar(line)
+}
+
+func (p *yamlParser) sequence(indent int) ([]interface{}, error) {
+	var items = []interface{}{}
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && isSequenceItem(p.lines[p.i].text) {
+		var line = p.lines[p.i]
+		var rest = strings.TrimLeft(line.text[1:], " ")
+		if rest == "" {
+			p.i++
+			if p.i == len(p.lines) || p.lines[p.i].indent <= indent {
+				items = append(items, nil)
+				continue
+			}
+			var item, err = p.value(indent + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+
+		// the item starts on the line of the dash, e.g. "- name: id", and
+		// continues on the lines indented like its first line
+		p.lines[p.i] = yamlLine{num: line.num, indent: indent + len(line.text) - len(rest), text: rest}
+		var item, err = p.value(p.lines[p.i].indent)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (p *yamlParser) mapping(indent int) (map[string]interface{}, error) {
+	var m = map[string]interface{}{}
+	for p.i < len(p.lines) && p.lines[p.i].indent == indent && !isSequenceItem(p.lines[p.i].text) {
+		var line = p.lines[p.i]
+		var key, rest, ok = splitKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a key", line.num)
+		}
+		if _, dup := m[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %s", line.num, key)
+		}
+		p.i++
+
+		if rest != "" {
+			var v, err = scalar(yamlLine{num: line.num, text: rest})
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+			continue
+		}
+		if p.i == len(p.lines) {
+			m[key] = nil
+			continue
+		}
+		// sequences may be indented like the key they belong to
+		var next = p.lines[p.i]
+		if next.indent > indent || (next.indent == indent && isSequenceItem(next.text)) {
+			var v, err = p.value(next.indent)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = v
+		} else {
+			m[key] = nil
+		}
+	}
+	return m, nil
+}
+
+// splitKey splits "key: value" and "key:" lines.
+func splitKey(text string) (key, rest string, ok bool) {
+	var i = strings.Index(text, ": ")
+	if i < 0 {
+		if !strings.HasSuffix(text, ":") {
+			return "", "", false
+		}
+		i = len(text) - 1
+	}
+	key = text[:i]
+	if key == "" || strings.ContainsAny(key[:1], `"'[{`) {
+		return "", "", false
+	}
+	return key, strings.TrimLeft(text[i+1:], " "), true
+}
+
+func scalar(line yamlLine) (interface{}, error) {
+	var text = line.text
+	switch {
+	case text == "[]":
+		return []interface{}{}, nil
+	case text == "~" || text == "null":
+		return nil, nil
+	case strings.HasPrefix(text, `"`):
+		var s, err = strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid string %s", line.num, text)
+		}
+		return s, nil
+	case strings.HasPrefix(text, "'"):
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, fmt.Errorf("line %d: invalid string %s", line.num, text)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+	return text, nil
+}
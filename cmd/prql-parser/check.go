@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/siadat/prql-parser/catalog"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/types"
+)
+
+// runCheck implements the check subcommand. It reports the unknown tables
+// and columns and the type errors of the queries in the given files, or
+// stdin, and returns the exit code.
+func runCheck(args []string) int {
+	var flags = flag.NewFlagSet("check", flag.ExitOnError)
+	var catalogPath = flags.String("catalog", "", "table definitions: a .json, .yaml (block style only) or .sql (CREATE TABLE) file")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: prql-parser check -catalog path [path ...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *catalogPath == "" {
+		flags.Usage()
+		return 2
+	}
+	var c, err = catalog.Load(*catalogPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if flags.NArg() == 0 {
+		return checkFile(c, "<standard input>", os.Stdin)
+	}
+	var exitCode = 0
+	for _, path := range flags.Args() {
+		var f, err = os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 1
+			continue
+		}
+		if code := checkFile(c, path, f); code != 0 {
+			exitCode = code
+		}
+		f.Close()
+	}
+	return exitCode
+}
+
+func checkFile(c *catalog.Catalog, path string, in io.Reader) int {
+	var root, err = parser.NewParser().Parse(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		return 1
+	}
+	var _, errs = types.Check(root, c)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+	}
+	if len(errs) > 0 {
+		return 1
+	}
+	return 0
+}
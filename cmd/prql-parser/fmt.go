@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/siadat/prql-parser/internal/diff"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/printer"
+	"github.com/siadat/prql-parser/simplify"
+)
+
+type fmtOptions struct {
+	cfg      printer.Config
+	write    bool
+	showDiff bool
+	simplify bool
+}
+
+// runFmt implements the fmt subcommand. It formats the given files, or stdin
+// if none are given, and returns the exit code.
+func runFmt(args []string) int {
+	var flags = flag.NewFlagSet("fmt", flag.ExitOnError)
+	var write = flags.Bool("w", false, "write result to (source) file instead of stdout")
+	var showDiff = flags.Bool("d", false, "display diffs instead of rewriting files")
+	var width = flags.Int("width", printer.DefaultWidth, "line width above which bracket lists are wrapped")
+	var simplifyCode = flags.Bool("s", false, "simplify code: fold constant arithmetic and report division by zero")
+	var braces = flags.Bool("braces", false, "print lists with braces, migrating [a, b] to {a, b}")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: prql-parser fmt [flags] [path ...]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var opts = fmtOptions{
+		cfg:      printer.Config{Width: *width, Braces: *braces},
+		write:    *write,
+		showDiff: *showDiff,
+		simplify: *simplifyCode,
+	}
+
+	if flags.NArg() == 0 {
+		if opts.write {
+			fmt.Fprintln(os.Stderr, "prql-parser fmt: cannot use -w with standard input")
+			return 2
+		}
+		if err := formatFile(&opts, "<standard input>", os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	var exitCode = 0
+	for _, path := range flags.Args() {
+		var f, err = os.Open(path)
+		if err == nil {
+			err = formatFile(&opts, path, f, os.Stdout)
+			f.Close()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+func formatFile(opts *fmtOptions, path string, in io.Reader, out io.Writer) error {
+	var src, err = io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+
+	var p = parser.NewParser()
+	p.SetParseComments(true)
+	var root, parseErr = p.Parse(bytes.NewReader(src))
+	if parseErr != nil {
+		return fmt.Errorf("%s: %v", path, parseErr)
+	}
+
+	if opts.simplify {
+		var diags []simplify.Diagnostic
+		root, diags = simplify.Root(root)
+		for _, d := range diags {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, d)
+		}
+	}
+
+	var formatted bytes.Buffer
+	if err := opts.cfg.Fprint(&formatted, root); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	if opts.showDiff {
+		var d = diff.Unified(path+".orig", path, src, formatted.Bytes())
+		_, err = out.Write(d)
+		return err
+	}
+	if opts.write {
+		if bytes.Equal(src, formatted.Bytes()) {
+			return nil
+		}
+		var info, err = os.Stat(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, formatted.Bytes(), info.Mode().Perm())
+	}
+	_, err = out.Write(formatted.Bytes())
+	return err
+}
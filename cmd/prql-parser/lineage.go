@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/siadat/prql-parser/catalog"
+	"github.com/siadat/prql-parser/lineage"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/resolver"
+)
+
+// runLineage implements the lineage subcommand. It prints the source columns
+// of the output columns of the query in the given file, or stdin, and
+// returns the exit code.
+func runLineage(args []string) int {
+	var flags = flag.NewFlagSet("lineage", flag.ExitOnError)
+	var catalogPath = flags.String("catalog", "", "table definitions used to check column names: a .json, .yaml (block style only) or .sql (CREATE TABLE) file")
+	var dot = flags.Bool("dot", false, "print a Graphviz graph instead of JSON")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: prql-parser lineage [flags] [path]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var schema resolver.Schema
+	if *catalogPath != "" {
+		var c, err = catalog.Load(*catalogPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		schema = c.Schema()
+	}
+
+	var in io.Reader = os.Stdin
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		var f, err = os.Open(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	var root, parseErr = parser.NewParser().Parse(in)
+	if parseErr != nil {
+		fmt.Fprintln(os.Stderr, parseErr)
+		return 1
+	}
+	var l, errs = lineage.Extract(root, schema)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return 1
+	}
+
+	if *dot {
+		if err := l.WriteDOT(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	var b, err = json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(string(b))
+	return 0
+}
@@ -1,19 +1,84 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/kr/pretty"
 	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/pl"
+	"github.com/siadat/prql-parser/rq"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "fmt":
+			os.Exit(runFmt(os.Args[2:]))
+		case "sql":
+			os.Exit(runSQL(os.Args[2:]))
+		case "check":
+			os.Exit(runCheck(os.Args[2:]))
+		case "lineage":
+			os.Exit(runLineage(os.Args[2:]))
+		}
+	}
+
+	var format = flag.String("format", "pretty", "output format: pretty, json (see docs/ast-json.md), pl (PRQL compiler PL AST) or rq (relational IR)")
+	var optimize = flag.String("optimize", "", "comma-separated optimizer passes to run for -format=rq (fold, merge, pushdown, prune), or all")
+	flag.Parse()
+
 	var p = parser.NewParser()
+	p.SetParseComments(true)
 	var got, parseErr = p.Parse(os.Stdin)
 	if parseErr != nil {
 		fmt.Println(parseErr)
 		return
 	}
-	fmt.Printf("%# v\n", pretty.Formatter(got))
+
+	switch *format {
+	case "pretty":
+		fmt.Printf("%# v\n", pretty.Formatter(got))
+	case "json":
+		var b, err = json.MarshalIndent(got, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println(string(b))
+	case "pl":
+		var b, err = pl.Marshal(got)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println(string(b))
+	case "rq":
+		var rel, err = rq.Lower(got)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		switch *optimize {
+		case "":
+		case "all":
+			rel = rq.Optimize(rel)
+		default:
+			for _, name := range strings.Split(*optimize, ",") {
+				var pass, ok = rq.LookupPass(name)
+				if !ok {
+					fmt.Fprintf(os.Stderr, "unknown optimizer pass %q\n", name)
+					os.Exit(2)
+				}
+				rel = rq.Optimize(rel, pass)
+			}
+		}
+		rq.Fprint(os.Stdout, rel)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q\n", *format)
+		os.Exit(2)
+	}
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/sqlgen"
+)
+
+// runSQL implements the sql subcommand. It compiles the query in the given
+// file, or stdin, to SQL and returns the exit code. The -dialect flag takes
+// precedence over the dialect in the query header.
+func runSQL(args []string) int {
+	var flags = flag.NewFlagSet("sql", flag.ExitOnError)
+	var dialectName = flags.String("dialect", "", "SQL dialect: postgres, sqlite, mysql, mysql57 or duckdb (default: from the prql header, or postgres)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: prql-parser sql [flags] [path]\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var dialect sqlgen.Dialect
+	if *dialectName != "" {
+		var ok bool
+		if dialect, ok = sqlgen.LookupDialect(*dialectName); !ok {
+			fmt.Fprintf(os.Stderr, "unknown dialect %q\n", *dialectName)
+			return 2
+		}
+	}
+
+	var in io.Reader = os.Stdin
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		var f, err = os.Open(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	var root, parseErr = parser.NewParser().Parse(in)
+	if parseErr != nil {
+		fmt.Fprintln(os.Stderr, parseErr)
+		return 1
+	}
+	var sql, err = sqlgen.Generate(root, dialect)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Print(sql)
+	return 0
+}
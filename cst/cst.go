@@ -0,0 +1,86 @@
+// Package cst defines a lossless concrete syntax tree for PRQL.
+//
+// Every token of the source, including whitespace, newlines and comments, is
+// a leaf of the tree, so concatenating the leaves reproduces the source
+// byte-for-byte. Inner nodes group the tokens an AST node was parsed from and
+// point to that AST node, which makes the AST a typed view over the tree.
+package cst
+
+import (
+	"io"
+	"strings"
+
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/scanner"
+)
+
+type Node struct {
+	Token    scanner.Token // set on leaves
+	AST      ast.Node      // the AST node parsed from Children, nil on leaves
+	Children []*Node
+}
+
+func NewLeaf(t scanner.Token) *Node {
+	return &Node{Token: t}
+}
+
+func (n *Node) IsLeaf() bool {
+	return n.AST == nil
+}
+
+// Leaves returns the tokens of the tree in source order.
+func (n *Node) Leaves() []scanner.Token {
+	var leaves []scanner.Token
+	Inspect(n, func(n *Node) bool {
+		if n.IsLeaf() {
+			leaves = append(leaves, n.Token)
+		}
+		return true
+	})
+	return leaves
+}
+
+// String returns the source text of the tree.
+func (n *Node) String() string {
+	var b strings.Builder
+	n.WriteTo(&b)
+	return b.String()
+}
+
+func (n *Node) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, t := range n.Leaves() {
+		var written, err = io.WriteString(w, t.Lit)
+		total += int64(written)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Inspect traverses the tree in depth-first order, calling f for each node.
+// The children of a node are skipped if f returns false.
+func Inspect(n *Node, f func(*Node) bool) {
+	if !f(n) {
+		return
+	}
+	for _, c := range n.Children {
+		Inspect(c, f)
+	}
+}
+
+// Replace replaces the descendant old with new and reports whether old was
+// found. Only the tokens of old are affected when the tree is printed.
+func (n *Node) Replace(old, new *Node) bool {
+	for i, c := range n.Children {
+		if c == old {
+			n.Children[i] = new
+			return true
+		}
+		if c.Replace(old, new) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+// Package desugar rewrites syntactic sugar in PRQL queries into the
+// constructs it stands for, so that code generators only handle the latter.
+package desugar
+
+import (
+	"github.com/siadat/prql-parser/ast"
+)
+
+// Root returns a copy of root in which the pipes of the transforms are
+// replaced by nested calls, as Expr does.
+func Root(root *ast.Root) *ast.Root {
+	return &ast.Root{
+		Header:     root.Header,
+		Transforms: pipeline(root.Transforms),
+		Comments:   root.Comments,
+	}
+}
+
+// Expr returns e with pipes replaced by nested calls, e.g. round 2 amount for
+// (amount | round 2).
+func Expr(e ast.Expr) ast.Expr {
+	switch e := e.(type) {
+	case ast.PipeExpr:
+		var x = Expr(e.X)
+		for _, call := range e.Calls {
+			var c = Expr(call).(ast.CallExpr)
+			c.Args = append(c.Args, x)
+			x = c
+		}
+		return x
+	case ast.ParenExpr:
+		return ast.ParenExpr{X: Expr(e.X)}
+	case ast.AssignExpr:
+		return ast.AssignExpr{Name: e.Name, Expr: Expr(e.Expr)}
+	case ast.UnaryExpr:
+		return ast.UnaryExpr{X: Expr(e.X), Op: e.Op}
+	case ast.BinaryExpr:
+		return ast.BinaryExpr{X: Expr(e.X), Y: Expr(e.Y), Op: e.Op, OpPos: e.OpPos}
+	case ast.CallExpr:
+		var call = ast.CallExpr{Name: e.Name, Args: exprs(e.Args)}
+		for _, arg := range e.Named {
+			call.Named = append(call.Named, ast.NamedArg{Name: arg.Name, Value: Expr(arg.Value)})
+		}
+		return call
+	case ast.CaseExpr:
+		var arms = make([]ast.CaseArm, len(e.Arms))
+		for i, arm := range e.Arms {
+			arms[i] = ast.CaseArm{Cond: Expr(arm.Cond), Value: Expr(arm.Value)}
+		}
+		return ast.CaseExpr{Case: e.Case, Arms: arms}
+	case ast.RangeExpr:
+		if e.Start != nil {
+			e.Start = Expr(e.Start)
+		}
+		if e.End != nil {
+			e.End = Expr(e.End)
+		}
+		return e
+	case ast.ExprList:
+		return list(e)
+	case ast.Array:
+		return ast.Array{Items: exprs(e.Items)}
+	case ast.FuncLit:
+		return ast.FuncLit{Params: e.Params, Body: Expr(e.Body)}
+	default:
+		return e
+	}
+}
+
+func exprs(items []ast.Expr) []ast.Expr {
+	var desugared = make([]ast.Expr, len(items))
+	for i, item := range items {
+		desugared[i] = Expr(item)
+	}
+	return desugared
+}
+
+func list(list ast.ExprList) ast.ExprList {
+	return ast.ExprList{Items: exprs(list.Items), Braces: list.Braces}
+}
+
+func transform(node ast.Node) ast.Node {
+	switch node := node.(type) {
+	case ast.SelectTransform:
+		return ast.SelectTransform{List: list(node.List)}
+	case ast.DeriveTransform:
+		return ast.DeriveTransform{List: list(node.List)}
+	case ast.SortTransform:
+		return ast.SortTransform{List: list(node.List)}
+	case ast.FilterTransform:
+		return ast.FilterTransform{Expr: Expr(node.Expr)}
+	case ast.TakeTransform:
+		return ast.TakeTransform{Expr: Expr(node.Expr)}
+	case ast.WindowTransform:
+		var named = make([]ast.NamedArg, len(node.Named))
+		for i, arg := range node.Named {
+			named[i] = ast.NamedArg{Name: arg.Name, Value: Expr(arg.Value)}
+		}
+		return ast.WindowTransform{Named: named, Pipeline: pipeline(node.Pipeline)}
+	case ast.GroupTransform:
+		return ast.GroupTransform{By: list(node.By), Pipeline: pipeline(node.Pipeline)}
+	case ast.CustomTransform:
+		return ast.CustomTransform{Name: node.Name, List: list(node.List)}
+	default:
+		return node
+	}
+}
+
+func pipeline(transforms []ast.Node) []ast.Node {
+	var desugared = make([]ast.Node, len(transforms))
+	for i, node := range transforms {
+		desugared[i] = transform(node)
+	}
+	return desugared
+}
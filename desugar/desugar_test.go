@@ -0,0 +1,49 @@
+package desugar_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/desugar"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/printer"
+)
+
+func TestRoot(tt *testing.T) {
+	var testCases = []struct {
+		src  string
+		want string
+	}{
+		{
+			src:  "from t\nderive x = (amount | round 2)",
+			want: "from t\nderive x = round 2 amount\n",
+		},
+		{
+			src:  "filter (a + 1 | in 1..3 | upper) and b",
+			want: "filter upper (in 1..3 (a + 1)) and b\n",
+		},
+		{
+			src:  "group [(name | lower)] (window rows:..(x | abs) (derive y = (z | abs)))",
+			want: "group [lower name] (window rows:..(abs x) (derive y = abs z))\n",
+		},
+		{
+			src:  "select {a = case [(z | abs) > 1 => [(z | abs)]], b = -(z | abs)}",
+			want: "select {a = case [abs z > 1 => [abs z]], b = -(abs z)}\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		var root, err = parser.NewParser().Parse(strings.NewReader(tc.src))
+		if err != nil {
+			tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", tc.src, err)
+		}
+		var b strings.Builder
+		if err := printer.Fprint(&b, desugar.Root(root)); err != nil {
+			tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", tc.src, err)
+		}
+		if diff := cmp.Diff(tc.want, b.String()); diff != "" {
+			tt.Fatalf("mismatching results\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.src, diff)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Package diff produces unified diffs of line-oriented text.
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const context = 3
+
+type op struct {
+	kind byte // ' ', '-' or '+'
+	line string
+
+	// indexes of the line in old and new, or of the line that follows it
+	// when it is missing from one of them
+	oldIdx, newIdx int
+}
+
+// Unified returns a unified diff of old and new, or nil if they are equal.
+func Unified(oldName, newName string, old, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+
+	var ops = lineOps(splitLines(string(old)), splitLines(string(new)))
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+
+	var i, prev = 0, 0
+	for i < len(ops) {
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		var start = i - context
+		if start < prev {
+			start = prev
+		}
+
+		// extend the hunk while the next change is close enough to share
+		// context lines with it
+		var end = i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			var j = end
+			for j < len(ops) && ops[j].kind == ' ' {
+				j++
+			}
+			if j == len(ops) || j-end > 2*context {
+				break
+			}
+			end = j
+		}
+		var stop = end + context
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+
+		writeHunk(&b, ops[start:stop])
+		i, prev = stop, stop
+	}
+
+	return b.Bytes()
+}
+
+func writeHunk(b *bytes.Buffer, ops []op) {
+	var oldCount, newCount int
+	for _, o := range ops {
+		if o.kind != '+' {
+			oldCount++
+		}
+		if o.kind != '-' {
+			newCount++
+		}
+	}
+
+	var oldStart = ops[0].oldIdx
+	if oldCount > 0 {
+		oldStart++
+	}
+	var newStart = ops[0].newIdx
+	if newCount > 0 {
+		newStart++
+	}
+
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, o := range ops {
+		b.WriteByte(o.kind)
+		b.WriteString(o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func splitLines(s string) []string {
+	var lines = strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineOps returns the edit script turning a into b, computed from the
+// longest common subsequence of their lines.
+func lineOps(a, b []string) []op {
+	var lcs = make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []op
+	var i, j = 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{' ', a[i], i, j})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{'-', a[i], i, j})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j], i, j})
+			j++
+		}
+	}
+	return ops
+}
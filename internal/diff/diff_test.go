@@ -0,0 +1,43 @@
+package diff_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/internal/diff"
+)
+
+func TestUnified(tt *testing.T) {
+	var testCases = []struct {
+		old, new string
+		want     string
+	}{
+		{
+			old:  "a\nb\n",
+			new:  "a\nb\n",
+			want: "",
+		},
+		{
+			old:  "from t\nselect [a,b]\n",
+			new:  "from t\nselect [a, b]\n",
+			want: "--- old\n+++ new\n@@ -1,2 +1,2 @@\n from t\n-select [a,b]\n+select [a, b]\n",
+		},
+		{
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			new:  "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n12\n",
+			want: "--- old\n+++ new\n@@ -1,3 +1,4 @@\n+0\n 1\n 2\n 3\n@@ -8,5 +9,4 @@\n 8\n 9\n 10\n-11\n 12\n",
+		},
+		{
+			old:  "from t",
+			new:  "from t\n",
+			want: "--- old\n+++ new\n@@ -1,1 +1,1 @@\n-from t\n\\ No newline at end of file\n+from t\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		var got = string(diff.Unified("old", "new", []byte(tc.old), []byte(tc.new)))
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			tt.Fatalf("mismatching results\nold:\n%s\nnew:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.old, tc.new, diff)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Package lineage extracts the source columns that the output columns of
+// PRQL queries depend on.
+package lineage
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/printer"
+	"github.com/siadat/prql-parser/resolver"
+)
+
+// Lineage lists the output columns of a pipeline.
+type Lineage struct {
+	Columns []Column `json:"columns"`
+}
+
+// Column is an output column, the table columns it is computed from and the
+// assignments that compute it, in pipeline order. From is the table column or
+// the assignment that the output column is.
+type Column struct {
+	Name    string   `json:"name"`
+	From    Input    `json:"from"`
+	Sources []Source `json:"sources"`
+	Steps   []Step   `json:"steps"`
+}
+
+type Source struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+}
+
+func (s Source) String() string {
+	return s.Table + "." + s.Column
+}
+
+// Step is an assignment in a derive or select transform, at the item Item of
+// the transform Transform.
+type Step struct {
+	Name      string  `json:"name"`
+	Expr      string  `json:"expr"`
+	Transform int     `json:"transform"`
+	Item      int     `json:"item"`
+	Inputs    []Input `json:"inputs"`
+}
+
+// Input is a column referenced by the expression of a step: a column of
+// Table, or the column declared by the step at Transform and Item, which are
+// -1 for table columns.
+type Input struct {
+	Table     string `json:"table,omitempty"`
+	Column    string `json:"column"`
+	Transform int    `json:"transform"`
+	Item      int    `json:"item"`
+}
+
+func (in Input) id() string {
+	if in.Table != "" {
+		return Source{Table: in.Table, Column: in.Column}.String()
+	}
+	return stepID(in.Transform, in.Item)
+}
+
+func stepID(transform, item int) string {
+	return fmt.Sprintf("step.%d.%d", transform, item)
+}
+
+type decl struct {
+	step Step
+	// inputs are the columns referenced by the expression
+	inputs []resolver.Binding
+}
+
+type extractor struct {
+	bindings *resolver.Info
+	decls    map[ast.NodePath]decl
+	next     int // the Item of the next list item of the transform, see resolver
+}
+
+// Extract returns the lineage of the output columns of root. Columns are
+// resolved with resolver.Resolve and schema, which may be nil, and columns
+// interpolated in f-strings and s-strings are not followed.
+func Extract(root *ast.Root, schema resolver.Schema) (*Lineage, []resolver.Error) {
+	var bindings, errs = resolver.Resolve(root, schema)
+	var x = &extractor{bindings: bindings, decls: map[ast.NodePath]decl{}}
+	for i, node := range root.Transforms {
+		x.next = 0
+		x.transform(i, node)
+	}
+
+	var l = &Lineage{Columns: []Column{}}
+	if n := len(bindings.Scopes); n > 0 {
+		for _, b := range bindings.Scopes[n-1].Columns {
+			l.Columns = append(l.Columns, x.column(b))
+		}
+	}
+	return l, errs
+}
+
+func (x *extractor) transform(i int, node ast.Node) {
+	switch node := node.(type) {
+	case ast.DeriveTransform:
+		x.list(i, node.List)
+	case ast.SelectTransform:
+		x.list(i, node.List)
+	case ast.SortTransform:
+		x.next += len(node.List.Items)
+	case ast.CustomTransform:
+		x.next += len(node.List.Items)
+	case ast.WindowTransform:
+		for _, t := range node.Pipeline {
+			x.transform(i, t)
+		}
+	case ast.GroupTransform:
+		x.next += len(node.By.Items)
+		for _, t := range node.Pipeline {
+			x.transform(i, t)
+		}
+	}
+}
+
+func (x *extractor) list(transform int, list ast.ExprList) {
+	for _, item := range list.Items {
+		var i = x.next
+		x.next++
+		var assign, ok = item.(ast.AssignExpr)
+		if !ok {
+			continue
+		}
+		var d = decl{step: Step{Name: assign.Name, Expr: exprString(assign.Expr), Transform: transform, Item: i, Inputs: []Input{}}}
+		ast.Inspect(assign.Expr, func(node ast.Node) bool {
+			if c, ok := node.(ast.Column); ok {
+				if b, ok := x.bindings.Lookup(c); ok {
+					d.inputs = append(d.inputs, b)
+					d.step.Inputs = append(d.step.Inputs, input(b))
+				}
+			}
+			return true
+		})
+		x.decls[ast.NodePath{Transform: transform, Item: i}] = d
+	}
+}
+
+func input(b resolver.Binding) Input {
+	if b.Table != "" {
+		return Input{Table: b.Table, Column: b.Name, Transform: -1, Item: -1}
+	}
+	return Input{Column: b.Name, Transform: b.Decl.Transform, Item: b.Decl.Item}
+}
+
+func (x *extractor) column(b resolver.Binding) Column {
+	var sources = map[Source]bool{}
+	var steps = map[ast.NodePath]bool{}
+	x.follow(b, sources, steps)
+
+	var c = Column{Name: b.Name, From: input(b), Sources: []Source{}, Steps: []Step{}}
+	for s := range sources {
+		c.Sources = append(c.Sources, s)
+	}
+	sort.Slice(c.Sources, func(i, j int) bool {
+		return c.Sources[i].String() < c.Sources[j].String()
+	})
+	for path := range steps {
+		c.Steps = append(c.Steps, x.decls[path].step)
+	}
+	sort.Slice(c.Steps, func(i, j int) bool {
+		var a, b = c.Steps[i], c.Steps[j]
+		return a.Transform < b.Transform || (a.Transform == b.Transform && a.Item < b.Item)
+	})
+	return c
+}
+
+// follow adds the table columns and assignments that b depends on to sources
+// and steps.
+func (x *extractor) follow(b resolver.Binding, sources map[Source]bool, steps map[ast.NodePath]bool) {
+	if b.Table != "" {
+		sources[Source{Table: b.Table, Column: b.Name}] = true
+		return
+	}
+	if steps[b.Decl] {
+		return
+	}
+	steps[b.Decl] = true
+	for _, input := range x.decls[b.Decl].inputs {
+		x.follow(input, sources, steps)
+	}
+}
+
+func exprString(e ast.Expr) string {
+	var b strings.Builder
+	if err := printer.Fprint(&b, e); err != nil {
+		return fmt.Sprintf("%T", e)
+	}
+	return b.String()
+}
+
+// WriteDOT writes l as a Graphviz graph, with edges from the source columns
+// through the assignments to the output columns.
+func (l *Lineage) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph lineage {\n")
+	b.WriteString("\trankdir=LR\n")
+
+	var nodes = map[string]bool{}
+	var edges = map[string]bool{}
+	var node = func(id, attrs string) {
+		if !nodes[id] {
+			nodes[id] = true
+			fmt.Fprintf(&b, "\t%s [%s]\n", strconv.Quote(id), attrs)
+		}
+	}
+	var edge = func(from, to string) {
+		var e = fmt.Sprintf("\t%s -> %s\n", strconv.Quote(from), strconv.Quote(to))
+		if !edges[e] {
+			edges[e] = true
+			b.WriteString(e)
+		}
+	}
+
+	for _, c := range l.Columns {
+		var out = "output." + c.Name
+		node(out, fmt.Sprintf("label=%s, shape=box, style=bold", strconv.Quote(c.Name)))
+		for _, s := range c.Sources {
+			node(s.String(), "shape=box")
+		}
+		for _, step := range c.Steps {
+			var id = stepID(step.Transform, step.Item)
+			node(id, fmt.Sprintf("label=%s", strconv.Quote(step.Name+" = "+step.Expr)))
+			for _, in := range step.Inputs {
+				edge(in.id(), id)
+			}
+		}
+		edge(c.From.id(), out)
+	}
+	b.WriteString("}\n")
+	var _, err = io.WriteString(w, b.String())
+	return err
+}
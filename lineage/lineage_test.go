@@ -0,0 +1,104 @@
+package lineage_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/lineage"
+	"github.com/siadat/prql-parser/parser"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// TestGolden compares the lineage of testdata/*.prql with the corresponding
+// testdata/*.json and testdata/*.dot files.
+func TestGolden(tt *testing.T) {
+	var paths, err = filepath.Glob("testdata/*.prql")
+	if err != nil {
+		tt.Fatal(err)
+	}
+
+	for _, path := range paths {
+		var src, err = os.ReadFile(path)
+		if err != nil {
+			tt.Fatal(err)
+		}
+
+		var root, parseErr = parser.NewParser().Parse(bytes.NewReader(src))
+		if parseErr != nil {
+			tt.Fatalf("%s: %v", path, parseErr)
+		}
+		var l, errs = lineage.Extract(root, nil)
+		if len(errs) != 0 {
+			tt.Fatalf("%s: %v", path, errs)
+		}
+
+		var js, jsonErr = json.MarshalIndent(l, "", "  ")
+		if jsonErr != nil {
+			tt.Fatal(jsonErr)
+		}
+		var dot bytes.Buffer
+		if err := l.WriteDOT(&dot); err != nil {
+			tt.Fatal(err)
+		}
+
+		for ext, got := range map[string]string{".json": string(js) + "\n", ".dot": dot.String()} {
+			var golden = strings.TrimSuffix(path, ".prql") + ext
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					tt.Fatal(err)
+				}
+				continue
+			}
+			var want, readErr = os.ReadFile(golden)
+			if readErr != nil {
+				tt.Fatal(readErr)
+			}
+			if diff := cmp.Diff(string(want), got); diff != "" {
+				tt.Fatalf("mismatching results for %s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", golden, diff)
+			}
+		}
+	}
+}
+
+func TestSchema(tt *testing.T) {
+	var root, err = parser.NewParser().Parse(strings.NewReader("from t\nderive [b = a + 1]\nselect [b, c]"))
+	if err != nil {
+		tt.Fatal(err)
+	}
+	var l, errs = lineage.Extract(root, map[string][]string{"t": {"a", "b"}})
+
+	var gotErrs []string
+	for _, err := range errs {
+		gotErrs = append(gotErrs, err.Error())
+	}
+	if diff := cmp.Diff([]string{"unknown column c at 37"}, gotErrs); diff != "" {
+		tt.Fatalf("mismatching errors\ndiff guide:\n  - want\n  + got\ndiff:\n%s", diff)
+	}
+
+	var want = []lineage.Column{
+		{
+			Name:    "b",
+			From:    lineage.Input{Column: "b", Transform: 1, Item: 0},
+			Sources: []lineage.Source{{Table: "t", Column: "a"}},
+			Steps: []lineage.Step{
+				{
+					Name:      "b",
+					Expr:      "a + 1",
+					Transform: 1,
+					Item:      0,
+					Inputs:    []lineage.Input{{Table: "t", Column: "a", Transform: -1, Item: -1}},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, l.Columns); diff != "" {
+		tt.Fatalf("mismatching results\ndiff guide:\n  - want\n  + got\ndiff:\n%s", diff)
+	}
+}
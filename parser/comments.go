@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/scanner"
+)
+
+// addSpan records that the node at path starts at start and ends at the last
+// consumed token.
+func (p *Parser) addSpan(path ast.NodePath, start scanner.Pos) {
+	p.addSpanAt(path, start, p.lastEnd)
+}
+
+// addSpanAt records that the node at path starts at start and ends at end.
+func (p *Parser) addSpanAt(path ast.NodePath, start, end scanner.Pos) {
+	if !p.parseComments {
+		return
+	}
+	p.spans = append(p.spans, span{path: path, start: start, end: end})
+}
+
+// commentMap groups the collected comments and attaches each group to a
+// node. A trailing comment is attached to the node that ends last before it,
+// and other comments are attached to the node that starts first after them.
+// Transforms take precedence over their items when both qualify. Comments
+// within a node that spans lines, e.g. between the arms of a case, are
+// attached to the innermost such node unless a node within it qualifies, so
+// they stay with the expression they are written in.
+func (p *Parser) commentMap(transformCount int) ast.CommentMap {
+	if len(p.comments) == 0 {
+		return nil
+	}
+
+	var cmap = ast.CommentMap{}
+	for i := 0; i < len(p.comments); {
+		var c = p.comments[i]
+		var group = &ast.CommentGroup{List: []ast.Comment{c.Comment}}
+		i++
+		// consecutive comment lines form a single leading group
+		for !c.trailing && i < len(p.comments) && !p.comments[i].trailing && p.comments[i].tokenCount == c.tokenCount {
+			group.List = append(group.List, p.comments[i].Comment)
+			i++
+		}
+
+		var outer = p.nodeAround(c.Pos)
+		if c.trailing {
+			if s := p.nodeBefore(c.Pos); s != nil || outer != nil {
+				if !outer.contains(s) {
+					s = outer
+				}
+				var nc = cmap[s.path]
+				nc.Trailing = appendGroup(nc.Trailing, group)
+				cmap[s.path] = nc
+				continue
+			}
+		}
+
+		var path = ast.NodePath{Transform: transformCount, Item: -1}
+		if s := p.nodeAfter(c.Pos); !outer.contains(s) {
+			path = outer.path
+		} else if s != nil {
+			path = s.path
+		}
+		var nc = cmap[path]
+		nc.Leading = appendGroup(nc.Leading, group)
+		cmap[path] = nc
+	}
+	return cmap
+}
+
+// contains reports whether inner is within s. A nil s contains any span but
+// no span contains nil.
+func (s *span) contains(inner *span) bool {
+	if s == nil {
+		return true
+	}
+	return inner != nil && s.start <= inner.start && inner.end <= s.end
+}
+
+func (p *Parser) nodeBefore(pos scanner.Pos) *span {
+	var found *span
+	for i := range p.spans {
+		var s = &p.spans[i]
+		if s.end > pos {
+			continue
+		}
+		if found == nil || s.end > found.end || (s.end == found.end && s.path.Item == -1) {
+			found = s
+		}
+	}
+	return found
+}
+
+func (p *Parser) nodeAfter(pos scanner.Pos) *span {
+	var found *span
+	for i := range p.spans {
+		var s = &p.spans[i]
+		if s.start < pos {
+			continue
+		}
+		if found == nil || s.start < found.start || (s.start == found.start && s.path.Item == -1) {
+			found = s
+		}
+	}
+	return found
+}
+
+// nodeAround returns the innermost node that pos is within, or nil.
+func (p *Parser) nodeAround(pos scanner.Pos) *span {
+	var found *span
+	for i := range p.spans {
+		var s = &p.spans[i]
+		if s.start < pos && pos < s.end && (found == nil || found.contains(s)) {
+			found = s
+		}
+	}
+	return found
+}
+
+func appendGroup(dst, group *ast.CommentGroup) *ast.CommentGroup {
+	if dst == nil {
+		return group
+	}
+	dst.List = append(dst.List, group.List...)
+	return dst
+}
@@ -0,0 +1,142 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/scanner"
+)
+
+func TestComments(tt *testing.T) {
+	var testCases = []struct {
+		src  string
+		want ast.CommentMap
+	}{
+		{
+			src:  `from table1`,
+			want: nil,
+		},
+		{
+			src: `from table1 # comment`,
+			want: ast.CommentMap{
+				{Transform: 0, Item: -1}: {
+					Trailing: &ast.CommentGroup{List: []ast.Comment{{Text: "# comment", Pos: 12}}},
+				},
+			},
+		},
+		{
+			src: "# comment1\n# comment2\n\nfrom table1\n# comment3\nselect column1 # comment4\n# comment5",
+			want: ast.CommentMap{
+				{Transform: 0, Item: -1}: {
+					Leading: &ast.CommentGroup{List: []ast.Comment{
+						{Text: "# comment1", Pos: IgnorePos},
+						{Text: "# comment2", Pos: IgnorePos},
+					}},
+				},
+				{Transform: 1, Item: -1}: {
+					Leading:  &ast.CommentGroup{List: []ast.Comment{{Text: "# comment3", Pos: IgnorePos}}},
+					Trailing: &ast.CommentGroup{List: []ast.Comment{{Text: "# comment4", Pos: IgnorePos}}},
+				},
+				{Transform: 2, Item: -1}: {
+					Leading: &ast.CommentGroup{List: []ast.Comment{{Text: "# comment5", Pos: IgnorePos}}},
+				},
+			},
+		},
+		{
+			src: `
+			from table1
+			select [
+			  column1, # comment1
+			  # comment2
+			  column2 # comment3
+			] # comment4
+			`,
+			want: ast.CommentMap{
+				{Transform: 1, Item: -1}: {
+					Trailing: &ast.CommentGroup{List: []ast.Comment{{Text: "# comment4", Pos: IgnorePos}}},
+				},
+				{Transform: 1, Item: 0}: {
+					Trailing: &ast.CommentGroup{List: []ast.Comment{{Text: "# comment1", Pos: IgnorePos}}},
+				},
+				{Transform: 1, Item: 1}: {
+					Leading:  &ast.CommentGroup{List: []ast.Comment{{Text: "# comment2", Pos: IgnorePos}}},
+					Trailing: &ast.CommentGroup{List: []ast.Comment{{Text: "# comment3", Pos: IgnorePos}}},
+				},
+			},
+		},
+		{
+			src: `
+			group a (
+			  # comment1
+			  derive x = 1 # comment2
+			  window (
+			    sort [
+			      y, # comment3
+			    ]
+			    # comment4
+			  )
+			  # comment5
+			) # comment6
+			`,
+			want: ast.CommentMap{
+				{Transform: 0, Item: -1}: {
+					Trailing: &ast.CommentGroup{List: []ast.Comment{{Text: "# comment6", Pos: IgnorePos}}},
+				},
+				{Transform: 0, Item: -1, Nested: 1}: {
+					Leading:  &ast.CommentGroup{List: []ast.Comment{{Text: "# comment1", Pos: IgnorePos}}},
+					Trailing: &ast.CommentGroup{List: []ast.Comment{{Text: "# comment2", Pos: IgnorePos}}},
+				},
+				{Transform: 0, Item: 2}: {
+					Trailing: &ast.CommentGroup{List: []ast.Comment{{Text: "# comment3", Pos: IgnorePos}}},
+				},
+				{Transform: 0, Item: -1, Nested: 4}: {
+					Leading: &ast.CommentGroup{List: []ast.Comment{{Text: "# comment4", Pos: IgnorePos}}},
+				},
+				{Transform: 0, Item: -1, Nested: 5}: {
+					Leading: &ast.CommentGroup{List: []ast.Comment{{Text: "# comment5", Pos: IgnorePos}}},
+				},
+			},
+		},
+		{
+			src: `
+			filter a # comment1
+			derive [
+			  x = 1,
+			  y = case [
+			    # comment2
+			    a => 1, # comment3
+			  ],
+			]
+			`,
+			want: ast.CommentMap{
+				{Transform: 0, Item: -1}: {
+					Trailing: &ast.CommentGroup{List: []ast.Comment{{Text: "# comment1", Pos: IgnorePos}}},
+				},
+				{Transform: 1, Item: 1}: {
+					Leading:  &ast.CommentGroup{List: []ast.Comment{{Text: "# comment2", Pos: IgnorePos}}},
+					Trailing: &ast.CommentGroup{List: []ast.Comment{{Text: "# comment3", Pos: IgnorePos}}},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		var p = parser.NewParser()
+		p.SetParseComments(true)
+		var src = tc.src
+		var got, err = p.Parse(strings.NewReader(src))
+		src = formatSrc(src, true)
+		if err != nil {
+			tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", src, err)
+		}
+
+		var cmpOpt = cmp.FilterValues(func(p1, p2 scanner.Pos) bool { return p1 == IgnorePos || p2 == IgnorePos || p1 == p2 }, cmp.Ignore())
+
+		if diff := cmp.Diff(tc.want, got.Comments, cmpOpt); diff != "" {
+			tt.Fatalf("mismatching results\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", src, diff)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"io"
+
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/cst"
+	"github.com/siadat/prql-parser/scanner"
+)
+
+// ParseCST parses src into a lossless concrete syntax tree. The root node's
+// AST is the *ast.Root that Parse would return.
+func (p *Parser) ParseCST(src io.Reader) (*cst.Node, error) {
+	p.lossless = true
+	defer func() { p.lossless = false }()
+
+	var root, err = p.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	// the trivia at the end of the input and the EOF token
+	p.consume(p.scanner.CurrToken())
+
+	return &cst.Node{AST: root, Children: p.nodes}, nil
+}
+
+// open returns a mark for the node that starts with the next consumed token.
+func (p *Parser) open() int {
+	if !p.lossless {
+		return 0
+	}
+	p.nodes = append(p.nodes, p.trivia...)
+	p.trivia = nil
+	return len(p.nodes)
+}
+
+// lastMark returns a mark for the last completed node.
+func (p *Parser) lastMark() int {
+	if !p.lossless {
+		return 0
+	}
+	return len(p.nodes) - 1
+}
+
+// close groups the nodes completed since mark into a node for the given AST
+// node.
+func (p *Parser) close(mark int, node ast.Node) {
+	if !p.lossless {
+		return
+	}
+	var children = make([]*cst.Node, len(p.nodes)-mark)
+	copy(children, p.nodes[mark:])
+	p.nodes = append(p.nodes[:mark], &cst.Node{AST: node, Children: children})
+}
+
+func (p *Parser) consume(t scanner.Token) {
+	if !p.lossless {
+		return
+	}
+	p.nodes = append(p.nodes, p.trivia...)
+	p.nodes = append(p.nodes, cst.NewLeaf(t))
+	p.trivia = nil
+}
@@ -0,0 +1,105 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/cst"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/scanner"
+	"github.com/siadat/prql-parser/token"
+)
+
+func TestCSTLossless(tt *testing.T) {
+	var testCases = []string{
+		``,
+		`from table1`,
+		"  # comment\n\nfrom e1 =\ttable1   # comment\n",
+		"from table1\n\n \n  \n# comment1 \n   # comment2 \n \t select column1 # comment3",
+		`
+		select [
+		  1, 1+2, 1 * 2, # 2 expressions in one line
+		  +3 + -2.1, # signed numbers
+		  expr1 = 1 + 2 * 3 * 4 + 5,
+		  z = ((z*2) + 1),
+		  @2022-12-31, "hello world", 123seconds,
+		]
+		derive x = 5
+		`,
+		"prql dialect:sqlite # comment\nfrom table1\ntake 10\n",
+		"from table1\ntake  5 .. 10 # comment\ntake ..3\n",
+		"derive [r = round  2 price, t = trim chars: \"x\" (name) # comment\n]\n",
+		"select {a = x, # comment\n  b}\nderive t = { 1 , [c] }\n",
+		"derive x = ( a + 1 |round 2|  in 1..3 ) # comment\n",
+		"derive [f = x->x + 1, g = ( a  b -> a * b )] # comment\n",
+		"group [dept] ( # comment\n  sort date\n  | window rows:-3 .. 0 (derive r = average value)\n)\n",
+	}
+
+	for _, src := range testCases {
+		var p = parser.NewParser()
+		var node, err = p.ParseCST(strings.NewReader(src))
+		if err != nil {
+			tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", formatSrc(src, true), err)
+		}
+
+		if diff := cmp.Diff(src, node.String()); diff != "" {
+			tt.Fatalf("mismatching results\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", formatSrc(src, true), diff)
+		}
+
+		var want, _ = parser.NewParser().Parse(strings.NewReader(src))
+		var cmpOpt = cmp.FilterValues(func(p1, p2 scanner.Pos) bool { return p1 == IgnorePos || p2 == IgnorePos || p1 == p2 }, cmp.Ignore())
+		if diff := cmp.Diff(want, node.AST, cmpOpt); diff != "" {
+			tt.Fatalf("mismatching AST\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", formatSrc(src, true), diff)
+		}
+	}
+}
+
+func TestCSTNodes(tt *testing.T) {
+	var src = "from e1 = table1\nselect [a, b + 1] # comment\n"
+	var node, err = parser.NewParser().ParseCST(strings.NewReader(src))
+	if err != nil {
+		tt.Fatal(err)
+	}
+
+	// the source text of every AST node
+	var got []string
+	cst.Inspect(node, func(n *cst.Node) bool {
+		if !n.IsLeaf() {
+			got = append(got, n.String())
+		}
+		return true
+	})
+	var want = []string{
+		src,
+		"from e1 = table1",
+		"select [a, b + 1]",
+		"[a, b + 1]",
+		"a",
+		"b + 1",
+		"b",
+		"1",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		tt.Fatalf("mismatching nodes\ndiff guide:\n  - want\n  + got\ndiff:\n%s", diff)
+	}
+
+	// renaming a column only changes its token
+	cst.Inspect(node, func(n *cst.Node) bool {
+		if column, ok := n.AST.(ast.Column); ok && column.Name.Name == "b" {
+			var renamed = n.Children[0].Token
+			renamed.Lit = "column2"
+			node.Replace(n.Children[0], cst.NewLeaf(renamed))
+		}
+		return true
+	})
+	if diff := cmp.Diff("from e1 = table1\nselect [a, column2 + 1] # comment\n", node.String()); diff != "" {
+		tt.Fatalf("mismatching edit\ndiff guide:\n  - want\n  + got\ndiff:\n%s", diff)
+	}
+
+	var leaves = node.Leaves()
+	if last := leaves[len(leaves)-1]; last.Typ != token.EOF {
+		tt.Fatalf("expected the last leaf to be EOF, got %s", last)
+	}
+}
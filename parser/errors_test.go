@@ -34,6 +34,70 @@ func TestErrors(tt *testing.T) {
 			`,
 			want: `unexpected token IDENTIFIER("b") at 32`,
 		},
+		{
+			src:  `select {a, b]`,
+			want: `unexpected token RBRACK("]") at 12`,
+		},
+		{
+			src:  `derive x = (a | round)`,
+			want: `round expects 2 arguments, got 1 at 16`,
+		},
+		{
+			src:  `derive x = (a | b)`,
+			want: `unknown function b at 16`,
+		},
+		{
+			src:  `derive x = (a | text.has "b")`,
+			want: `unknown function text.has at 16`,
+		},
+		{
+			src:  `filter a ~ "b"`,
+			want: `unexpected character ~`,
+		},
+		{
+			src:  `derive f = (a b + 1)`,
+			want: `expected ARROW, got ADD("+") at 16`,
+		},
+		{
+			src:  `derive x = 9223372036854775808`,
+			want: `integer out of range, got INTEGER("9223372036854775808") at 11`,
+		},
+		{
+			src:  `derive x = 9223372036854775808days`,
+			want: `integer out of range, got INTERVAL("9223372036854775808days") at 11`,
+		},
+		{
+			src:  `derive r = rank salary`,
+			want: `rank expects 0 arguments, got 1 at 11`,
+		},
+		{
+			src:  `derive x = round price`,
+			want: `round expects 2 arguments, got 1 at 11`,
+		},
+		{
+			src:  `derive x = trim width:2 name`,
+			want: `trim has no parameter width at 11`,
+		},
+		{
+			src:  `window size:3 (derive x = sum a)`,
+			want: `window has no parameter size at 7`,
+		},
+		{
+			src:  "from t\nwindow rows:-3..0 (derive x = sum a",
+			want: `expected RPAREN, got EOF(:AnyLit:) at 42`,
+		},
+		{
+			src:  `derive x = case []`,
+			want: `case expects at least one arm at 11`,
+		},
+		{
+			src:  `derive x = case [a > 1 -> 2]`,
+			want: `expected FATARROW, got ARROW("->") at 23`,
+		},
+		{
+			src:  `frm table1`,
+			want: `unknown transform "frm" at 0`,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -22,51 +22,124 @@ func TestParseExpression(tt *testing.T) {
 			src:  `123`,
 			want: ast.Integer{Value: 123},
 		},
+		{
+			src:  `rank`,
+			want: ast.CallExpr{Name: ast.Ident{Name: "rank", Pos: IgnorePos}},
+		},
+		{
+			src: `row_number + 1`,
+			want: ast.BinaryExpr{
+				OpPos: IgnorePos,
+				X:     ast.CallExpr{Name: ast.Ident{Name: "row_number", Pos: IgnorePos}},
+				Y:     ast.Integer{Value: 1},
+				Op:    token.ADD,
+			},
+		},
+		{
+			src:  `e.first_name`,
+			want: ast.Column{Name: ast.Ident{Name: "e.first_name", Pos: IgnorePos}},
+		},
 		{
 			src: `1 * 2`,
 			want: ast.BinaryExpr{
-				X:  ast.Integer{Value: 1},
-				Y:  ast.Integer{Value: 2},
-				Op: token.MUL,
+				OpPos: IgnorePos,
+				X:     ast.Integer{Value: 1},
+				Y:     ast.Integer{Value: 2},
+				Op:    token.MUL,
 			},
 		},
 		{
-			src: `1 + 2 * 3 * 4 + 5 # == 1 + ((2 * (3 * 4)) + 5)`,
+			src: `1 + 2 * 3 * 4 + 5 # == (1 + ((2 * 3) * 4)) + 5`,
 			want: ast.BinaryExpr{
-				X: ast.Integer{Value: 1},
-				Y: ast.BinaryExpr{
-					X: ast.BinaryExpr{
-						X: ast.Integer{Value: 2},
-						Y: ast.BinaryExpr{
-							X:  ast.Integer{Value: 3},
-							Y:  ast.Integer{Value: 4},
-							Op: token.MUL,
+				OpPos: IgnorePos,
+				X: ast.BinaryExpr{
+					OpPos: IgnorePos,
+					X:     ast.Integer{Value: 1},
+					Y: ast.BinaryExpr{
+						OpPos: IgnorePos,
+						X: ast.BinaryExpr{
+							OpPos: IgnorePos,
+							X:     ast.Integer{Value: 2},
+							Y:     ast.Integer{Value: 3},
+							Op:    token.MUL,
 						},
+						Y:  ast.Integer{Value: 4},
 						Op: token.MUL,
 					},
-					Y:  ast.Integer{Value: 5},
 					Op: token.ADD,
 				},
+				Y:  ast.Integer{Value: 5},
 				Op: token.ADD,
 			},
 		},
 		{
-			src: `1 * 2 + 3 + 4 * 5 # == (1 * 2) + (3 + (4 * 5))`,
+			src: `a // 2 ** b ** 2 # == a // (2 ** (b ** 2))`,
 			want: ast.BinaryExpr{
-				X: ast.BinaryExpr{
-					X:  ast.Integer{Value: 1},
-					Y:  ast.Integer{Value: 2},
-					Op: token.MUL,
-				},
+				OpPos: IgnorePos,
+				X:     ast.Column{Name: ast.Ident{Name: "a", Pos: IgnorePos}},
 				Y: ast.BinaryExpr{
-					X: ast.Integer{Value: 3},
+					OpPos: IgnorePos,
+					X:     ast.Integer{Value: 2},
 					Y: ast.BinaryExpr{
-						X:  ast.Integer{Value: 4},
-						Y:  ast.Integer{Value: 5},
-						Op: token.MUL,
+						OpPos: IgnorePos,
+						X:     ast.Column{Name: ast.Ident{Name: "b", Pos: IgnorePos}},
+						Y:     ast.Integer{Value: 2},
+						Op:    token.POW,
 					},
+					Op: token.POW,
+				},
+				Op: token.INTDIV,
+			},
+		},
+		{
+			src: `a - b - c # == (a - b) - c`,
+			want: ast.BinaryExpr{
+				OpPos: IgnorePos,
+				X: ast.BinaryExpr{
+					OpPos: IgnorePos,
+					X:     ast.Column{Name: ast.Ident{Name: "a", Pos: IgnorePos}},
+					Y:     ast.Column{Name: ast.Ident{Name: "b", Pos: IgnorePos}},
+					Op:    token.SUB,
+				},
+				Y:  ast.Column{Name: ast.Ident{Name: "c", Pos: IgnorePos}},
+				Op: token.SUB,
+			},
+		},
+		{
+			src: `a // b // c # == (a // b) // c`,
+			want: ast.BinaryExpr{
+				OpPos: IgnorePos,
+				X: ast.BinaryExpr{
+					OpPos: IgnorePos,
+					X:     ast.Column{Name: ast.Ident{Name: "a", Pos: IgnorePos}},
+					Y:     ast.Column{Name: ast.Ident{Name: "b", Pos: IgnorePos}},
+					Op:    token.INTDIV,
+				},
+				Y:  ast.Column{Name: ast.Ident{Name: "c", Pos: IgnorePos}},
+				Op: token.INTDIV,
+			},
+		},
+		{
+			src: `1 * 2 + 3 + 4 * 5 # == ((1 * 2) + 3) + (4 * 5)`,
+			want: ast.BinaryExpr{
+				OpPos: IgnorePos,
+				X: ast.BinaryExpr{
+					OpPos: IgnorePos,
+					X: ast.BinaryExpr{
+						OpPos: IgnorePos,
+						X:     ast.Integer{Value: 1},
+						Y:     ast.Integer{Value: 2},
+						Op:    token.MUL,
+					},
+					Y:  ast.Integer{Value: 3},
 					Op: token.ADD,
 				},
+				Y: ast.BinaryExpr{
+					OpPos: IgnorePos,
+					X:     ast.Integer{Value: 4},
+					Y:     ast.Integer{Value: 5},
+					Op:    token.MUL,
+				},
 				Op: token.ADD,
 			},
 		},
@@ -1,15 +1,19 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"runtime/debug"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/cst"
 	"github.com/siadat/prql-parser/scanner"
+	"github.com/siadat/prql-parser/stdlib"
 	"github.com/siadat/prql-parser/token"
 )
 
@@ -18,6 +22,31 @@ type Parser struct {
 	root    *ast.Root
 	scanner *scanner.Scanner
 	debug   bool
+
+	parseComments bool
+	comments      []comment
+	spans         []span
+	transformIdx  int         // index of the transform being parsed
+	itemIdx       int         // index of the first item of the next list of the transform
+	nestedIdx     int         // number of the last nested transform or pipeline end, see ast.NodePath
+	lastEnd       scanner.Pos // end of the last consumed token, excluding newlines
+	tokenCount    int         // number of tokens read, excluding newlines and comments
+
+	lossless bool
+	nodes    []*cst.Node // completed concrete syntax tree nodes, see open and close
+	trivia   []*cst.Node // whitespace and comments not yet added to nodes
+}
+
+type comment struct {
+	ast.Comment
+	trailing   bool // on the same line as a preceding token
+	tokenCount int
+}
+
+// span is the source range of the node at path.
+type span struct {
+	path       ast.NodePath
+	start, end scanner.Pos
 }
 
 type ParseError struct {
@@ -29,22 +58,64 @@ func (e ParseError) Error() string {
 }
 
 func (p *Parser) proceed() scanner.Token {
-	var t, err = p.scanner.NextToken()
+	var curr = p.scanner.CurrToken()
+	if curr.Typ != token.NEWLINE {
+		p.lastEnd = curr.Pos + scanner.Pos(utf8.RuneCountInString(curr.Lit))
+	}
+	p.consume(curr)
+
+	var t, err = p.next()
 	p.checkErr(err)
 	return t
 }
 
+// next reads the next token, collecting the whitespace and comments before
+// it.
+func (p *Parser) next() (scanner.Token, error) {
+	var prev = p.scanner.CurrToken()
+	for {
+		var t, err = p.scanner.NextToken()
+		if err != nil || (t.Typ != token.COMMENT && t.Typ != token.WHITESPACE) {
+			if t.Typ != token.NEWLINE {
+				p.tokenCount++
+			}
+			return t, err
+		}
+		if p.lossless {
+			p.trivia = append(p.trivia, cst.NewLeaf(t))
+		}
+		if t.Typ == token.WHITESPACE {
+			continue
+		}
+		p.comments = append(p.comments, comment{
+			Comment:    ast.Comment{Text: t.Lit, Pos: t.Pos},
+			trailing:   p.tokenCount > 0 && prev.Typ != token.NEWLINE,
+			tokenCount: p.tokenCount,
+		})
+	}
+}
+
 func NewParser() *Parser {
 	return &Parser{}
 }
 
 func (p *Parser) init(src io.Reader) error {
 	p.scanner = scanner.NewScanner(src)
-	p.scanner.SetSkipWhitespace(true)
-	p.scanner.SetSkipComment(true)
+	p.scanner.SetSkipWhitespace(!p.lossless)
+	p.scanner.SetSkipComment(!p.parseComments && !p.lossless)
 	p.scanner.SetDebug(p.debug)
 
-	var _, err = p.scanner.NextToken()
+	p.comments = nil
+	p.spans = nil
+	p.nodes = nil
+	p.trivia = nil
+	p.transformIdx = 0
+	p.itemIdx = 0
+	p.nestedIdx = 0
+	p.lastEnd = 0
+	p.tokenCount = 0
+
+	var _, err = p.next()
 	return err
 }
 
@@ -55,19 +126,34 @@ func (p *Parser) SetDebug(debug bool) {
 	}
 }
 
+// SetParseComments makes Parse collect comments into ast.Root.Comments
+// instead of discarding them.
+func (p *Parser) SetParseComments(parseComments bool) {
+	p.parseComments = parseComments
+}
+
 func (p *Parser) Parse(src io.Reader) (retRoot *ast.Root, retErr error) {
 	if err := p.init(src); err != nil {
 		return nil, err
 	}
 
 	defer func() {
-		var err = recover()
-		if err, ok := err.(ParseError); ok {
+		switch err := recover().(type) {
+		case nil:
+		case ParseError:
 			retErr = err
+		default:
+			// not a syntax error, e.g. a bug in a TransformFunc
+			panic(err)
 		}
 	}()
 
-	retRoot = &ast.Root{Transforms: p.parseTransforms()}
+	retRoot = &ast.Root{}
+	retRoot.Header = p.parseHeader()
+	retRoot.Transforms = p.parseTransforms()
+	if p.parseComments {
+		retRoot.Comments = p.commentMap(len(retRoot.Transforms))
+	}
 	return
 }
 
@@ -77,20 +163,70 @@ func (p *Parser) ParseExpr(src io.Reader) (retExpr ast.Expr, retErr error) {
 	}
 
 	defer func() {
-		var err = recover()
-		if err, ok := err.(ParseError); ok {
+		switch err := recover().(type) {
+		case nil:
+		case ParseError:
 			retErr = err
+		default:
+			// not a syntax error, e.g. a bug in a TransformFunc
+			panic(err)
 		}
 	}()
 	retExpr = p.parseExpr(nil, token.LowestPrecedence)
 	return
 }
 
+// parseHeader parses the optional query header, e.g. prql dialect:sqlite
+func (p *Parser) parseHeader() *ast.QueryHeader {
+	p.checkErr(p.skipOptionalNewlines())
+	var start = p.scanner.CurrToken()
+	if start.Typ != token.IDENTIFIER || start.Lit != "prql" {
+		return nil
+	}
+
+	var mark = p.open()
+	p.proceed()
+
+	var header = &ast.QueryHeader{}
+	for {
+		switch t := p.scanner.CurrToken(); t.Typ {
+		case token.NEWLINE:
+			p.proceed()
+			fallthrough
+		case token.EOF:
+			p.close(mark, *header)
+			p.addSpan(ast.NodePath{Transform: -1, Item: -1}, start.Pos)
+			return header
+		default:
+			header.Args = append(header.Args, p.parseNamedArg())
+		}
+	}
+}
+
+// parseNamedArg parses name:value
+func (p *Parser) parseNamedArg() ast.NamedArg {
+	var name = p.expectType(token.IDENTIFIER)
+	p.proceed()
+	p.expectType(token.COLON)
+	p.proceed()
+	return ast.NamedArg{
+		Name:  ast.Ident{Name: name.Lit, Pos: name.Pos},
+		Value: p.parsePrimaryExpr(),
+	}
+}
+
 func (p *Parser) parseTransforms() []ast.Node {
 	var nodes []ast.Node
 	for {
+		p.transformIdx = len(nodes)
+		p.itemIdx = 0
+		p.nestedIdx = 0
+		var start = p.scanner.CurrToken().Pos
+		var mark = p.open()
 		var node = p.parseTransform(0)
 		if node != nil {
+			p.close(mark, node)
+			p.addSpan(ast.NodePath{Transform: len(nodes), Item: -1}, start)
 			nodes = append(nodes, node)
 		}
 		if p.scanner.Eof() {
@@ -101,20 +237,19 @@ func (p *Parser) parseTransforms() []ast.Node {
 
 func (p *Parser) parseTransform(indent int) ast.Node {
 	var t = p.scanner.CurrToken()
-	if t.Lit == "from" {
-		return p.parseFromTransform()
-	} else if t.Lit == "select" {
-		return p.parseSelectTransform()
-	} else if t.Lit == "derive" {
-		return p.parseDeriveTransform()
-	} else if t.Typ == token.NEWLINE {
+	switch t.Typ {
+	case token.NEWLINE:
 		p.proceed()
 		return nil
-	} else if t.Typ == token.EOF {
+	case token.EOF:
 		return nil
-	} else {
-		panic(ParseError{fmt.Errorf("failed to parse a transform, unexpected %s", t)})
+	case token.IDENTIFIER:
+		if parse, ok := lookupTransform(t.Lit); ok {
+			return parse(p)
+		}
+		panic(ParseError{fmt.Errorf("unknown transform %q at %d", t.Lit, t.Pos)})
 	}
+	panic(ParseError{fmt.Errorf("failed to parse a transform, unexpected %s", t)})
 }
 
 func (p *Parser) parseFromTransform() ast.Node {
@@ -123,7 +258,7 @@ func (p *Parser) parseFromTransform() ast.Node {
 	var ident1 scanner.Token
 	var ident2 scanner.Token
 
-	p.expect(token.IDENTIFIER, "from")
+	p.expect(token.IDENTIFIER, stdlib.From.Name)
 	p.proceed()
 
 	ident1 = p.expectType(token.IDENTIFIER)
@@ -155,6 +290,17 @@ func (p *Parser) parseFromTransform() ast.Node {
 }
 
 func (p *Parser) parsePrimaryExpr() ast.Expr {
+	if p.scanner.CurrToken().Typ == token.LPAREN {
+		return p.parseParenExpr()
+	}
+
+	var mark = p.open()
+	var expr = p.parsePrimaryExprNoParen()
+	p.close(mark, expr)
+	return expr
+}
+
+func (p *Parser) parsePrimaryExprNoParen() ast.Expr {
 	switch t := p.scanner.CurrToken(); t.Typ {
 	case token.STRING:
 		p.proceed()
@@ -162,10 +308,7 @@ func (p *Parser) parsePrimaryExpr() ast.Expr {
 		return ast.String{Value: t.Lit}
 	case token.IDENTIFIER:
 		p.proceed()
-
-		return ast.Column{
-			Name: ast.Ident{Name: t.Lit, Pos: t.Pos},
-		}
+		return p.parseIdentExpr(t)
 	case token.DATE:
 		p.proceed()
 
@@ -206,9 +349,7 @@ func (p *Parser) parsePrimaryExpr() ast.Expr {
 		for _, unit := range token.Units {
 			var idx = strings.Index(t.Lit, unit)
 			if idx != -1 {
-				var d, err = strconv.ParseInt(t.Lit[:idx], 10, 64)
-				p.checkErr(err)
-				return ast.Interval{Count: int(d), Unit: unit}
+				return ast.Interval{Count: p.parseInt(t, t.Lit[:idx]), Unit: unit}
 			}
 		}
 		panic(ParseError{fmt.Errorf("bad interval format %s", t)})
@@ -232,31 +373,225 @@ func (p *Parser) parsePrimaryExpr() ast.Expr {
 	case token.INTEGER:
 		p.proceed()
 
-		var d, err = strconv.ParseInt(t.Lit, 10, 64)
-		p.checkErr(err)
-		return ast.Integer{Value: int(d)}
+		return ast.Integer{Value: p.parseInt(t, t.Lit)}
 	case token.FLOAT:
 		p.proceed()
 
 		var f, err = strconv.ParseFloat(t.Lit, 64)
 		p.checkErr(err)
 		return ast.Float{Value: f}
-	case token.LPAREN:
-		return p.parseParenExpr()
+	case token.BOOLEAN:
+		p.proceed()
+
+		return ast.Boolean{Value: t.Lit == "true"}
+	case token.LBRACK:
+		return ast.Array{Items: p.parseTuple(nil).Items}
+	case token.LBRACE:
+		return p.parseTuple(nil)
 	default:
 		panic(ParseError{fmt.Errorf("failed to parse primary expression, got %s", t)})
 	}
 }
 
+// parseInt parses the digits of token t. Values that do not fit in an int64
+// are reported instead of being truncated.
+func (p *Parser) parseInt(t scanner.Token, digits string) int64 {
+	var d, err = strconv.ParseInt(digits, 10, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		panic(ParseError{fmt.Errorf("integer out of range, got %s", t)})
+	}
+	p.checkErr(err)
+	return d
+}
+
+// parseIdentExpr parses the expression starting with the identifier t, which
+// has been consumed: a call if t is the name of a function followed by
+// arguments, or else a column.
+func (p *Parser) parseIdentExpr(t scanner.Token) ast.Expr {
+	if t.Lit == "case" && p.scanner.CurrToken().Typ == token.LBRACK {
+		return p.parseCaseExpr(t)
+	}
+	t = p.parseQualified(t)
+	var ident = ast.Ident{Name: t.Lit, Pos: t.Pos}
+	if p.scanner.CurrToken().Typ == token.ARROW {
+		return p.parseFuncLit([]ast.Ident{ident})
+	}
+	// functions without parameters are called by their name alone, e.g.
+	// rank
+	var f, ok = stdlib.LookupFunc(t.Lit)
+	if !ok || !p.atArg() && len(f.Params) > 0 {
+		return ast.Column{Name: ident}
+	}
+
+	var call = p.parseArgs(ast.CallExpr{Name: ident})
+	if _, err := f.Bind(call); err != nil {
+		panic(ParseError{fmt.Errorf("%v at %d", err, t.Pos)})
+	}
+	return call
+}
+
+// parseArgs parses the arguments of call, whose name has been consumed.
+func (p *Parser) parseArgs(call ast.CallExpr) ast.CallExpr {
+	for p.atArg() {
+		var arg = p.scanner.CurrToken()
+		if arg.Typ == token.RANGE {
+			call.Args = append(call.Args, p.parseRange())
+			continue
+		}
+		if arg.Typ != token.IDENTIFIER {
+			call.Args = append(call.Args, p.parseRangeArg(p.parsePrimaryExpr()))
+			continue
+		}
+
+		// arguments are terms, so an identifier is a column unless it is
+		// the name of a named argument
+		var mark = p.open()
+		p.proceed()
+		if p.scanner.CurrToken().Typ == token.COLON {
+			p.proceed()
+			call.Named = append(call.Named, ast.NamedArg{
+				Name:  ast.Ident{Name: arg.Lit, Pos: arg.Pos},
+				Value: p.parsePrimaryExpr(),
+			})
+			continue
+		}
+		var column = ast.Column{Name: ast.Ident{Name: arg.Lit, Pos: arg.Pos}}
+		p.close(mark, column)
+		call.Args = append(call.Args, p.parseRangeArg(column))
+	}
+	return call
+}
+
+// parseCaseExpr parses the arms of a case expression, e.g.
+// case [score > 90 => "A", true => "C"], whose keyword t has been consumed.
+func (p *Parser) parseCaseExpr(t scanner.Token) ast.Expr {
+	var expr = ast.CaseExpr{Case: t.Pos}
+	p.expectType(token.LBRACK)
+	p.proceed()
+	for {
+		p.checkErr(p.skipOptionalNewlines())
+		if p.scanner.CurrToken().Typ == token.RBRACK {
+			p.proceed()
+			if len(expr.Arms) == 0 {
+				panic(ParseError{fmt.Errorf("case expects at least one arm at %d", t.Pos)})
+			}
+			return expr
+		}
+
+		var cond = p.parseExpr(nil, token.LowestPrecedence)
+		p.expectType(token.FATARROW)
+		p.proceed()
+		var value = p.parseExpr(nil, token.LowestPrecedence)
+		expr.Arms = append(expr.Arms, ast.CaseArm{Cond: cond, Value: value})
+
+		switch tk := p.scanner.CurrToken(); tk.Typ {
+		case token.COMMA:
+			p.proceed()
+		case token.NEWLINE, token.RBRACK:
+		default:
+			panic(ParseError{fmt.Errorf("unexpected token %s", tk)})
+		}
+	}
+}
+
+// parseQualified returns the identifier t, which has been consumed, joined
+// with the name that follows it if t qualifies that name, e.g. the module
+// text in text.contains or the relation e in e.first_name.
+func (p *Parser) parseQualified(t scanner.Token) scanner.Token {
+	if p.scanner.CurrToken().Typ != token.PERIOD {
+		return t
+	}
+	p.proceed()
+	var name = p.expectType(token.IDENTIFIER)
+	p.proceed()
+	t.Lit += "." + name.Lit
+	return t
+}
+
+// parseFuncLit parses the arrow and body of a function whose params have
+// been consumed.
+func (p *Parser) parseFuncLit(params []ast.Ident) ast.Expr {
+	p.expectType(token.ARROW)
+	p.proceed()
+	return ast.FuncLit{Params: params, Body: p.parseExpr(nil, token.LowestPrecedence)}
+}
+
+// parsePipeExpr parses the calls that x, parsed since mark, is piped to,
+// e.g. | round 2 in (amount | round 2).
+func (p *Parser) parsePipeExpr(mark int, x ast.Expr) ast.Expr {
+	var pipe = ast.PipeExpr{X: x}
+	for p.scanner.CurrToken().Typ == token.PIPE {
+		p.proceed()
+		var callMark = p.open()
+		var t = p.expectType(token.IDENTIFIER)
+		p.proceed()
+		t = p.parseQualified(t)
+		var f, ok = stdlib.LookupFunc(t.Lit)
+		if !ok {
+			panic(ParseError{fmt.Errorf("unknown function %s at %d", t.Lit, t.Pos)})
+		}
+		var call = p.parseArgs(ast.CallExpr{Name: ast.Ident{Name: t.Lit, Pos: t.Pos}})
+		p.close(callMark, call)
+
+		// the piped value is the last argument
+		var piped = call
+		piped.Args = append(append([]ast.Expr(nil), call.Args...), x)
+		if _, err := f.Bind(piped); err != nil {
+			panic(ParseError{fmt.Errorf("%v at %d", err, t.Pos)})
+		}
+		pipe.Calls = append(pipe.Calls, call)
+	}
+	p.close(mark, pipe)
+	return pipe
+}
+
+// atArg reports whether the current token starts an argument of a call.
+// Arguments that are not literals or columns must be parenthesized.
+func (p *Parser) atArg() bool {
+	switch p.scanner.CurrToken().Typ {
+	case token.IDENTIFIER, token.STRING, token.INTEGER, token.FLOAT, token.BOOLEAN,
+		token.DATE, token.TIME, token.TIMESTAMP, token.INTERVAL, token.LPAREN,
+		token.LBRACK, token.RANGE:
+		return true
+	}
+	return false
+}
+
+// parseRangeArg returns the argument start, which has just been parsed, or
+// the range it starts, e.g. 1..10 in in 1..10 x.
+func (p *Parser) parseRangeArg(start ast.Expr) ast.Expr {
+	if p.scanner.CurrToken().Typ != token.RANGE {
+		return start
+	}
+	return p.parseRangeEnd(p.lastMark(), start)
+}
+
 func (p *Parser) parseParenExpr() ast.Expr {
+	var mark = p.open()
 	p.expect(token.LPAREN, "(")
 	p.proceed()
 	var expr = p.parseExpr(nil, token.LowestPrecedence)
+	if p.scanner.CurrToken().Typ == token.PIPE {
+		expr = p.parsePipeExpr(p.lastMark(), expr)
+	}
+	if column, ok := expr.(ast.Column); ok && p.scanner.CurrToken().Typ == token.IDENTIFIER {
+		// the parameters of a function, e.g. (a b -> a + b)
+		var mark = p.lastMark()
+		var params = []ast.Ident{column.Name}
+		for t := p.scanner.CurrToken(); t.Typ == token.IDENTIFIER; t = p.scanner.CurrToken() {
+			params = append(params, ast.Ident{Name: t.Lit, Pos: t.Pos})
+			p.proceed()
+		}
+		expr = p.parseFuncLit(params)
+		p.close(mark, expr)
+	}
 
 	p.expect(token.RPAREN, ")")
 	p.proceed()
 
-	return ast.ParenExpr{X: expr}
+	var paren = ast.ParenExpr{X: expr}
+	p.close(mark, paren)
+	return paren
 }
 
 func (p *Parser) checkErr(err error) {
@@ -268,9 +603,15 @@ func (p *Parser) checkErr(err error) {
 	}
 }
 
+// parseExpr parses a binary expression. If lhs is not nil, it must be the
+// last parsed node and is used as the left-hand side.
 func (p *Parser) parseExpr(lhs ast.Expr, minPrec token.Precedence) ast.Expr {
+	var mark int
 	if lhs == nil {
+		mark = p.open()
 		lhs = p.parsePrimaryExpr()
+	} else {
+		mark = p.lastMark()
 	}
 
 	for {
@@ -288,12 +629,20 @@ func (p *Parser) parseExpr(lhs ast.Expr, minPrec token.Precedence) ast.Expr {
 
 		p.proceed()
 
-		var rhs = p.parseExpr(nil, prec)
+		// the right-hand side of a left-associative operator stops at the
+		// next operator of the same precedence
+		var rhsPrec = prec + 1
+		if tk.Typ.RightAssociative() {
+			rhsPrec = prec
+		}
+		var rhs = p.parseExpr(nil, rhsPrec)
 		lhs = ast.BinaryExpr{
-			X:  lhs,
-			Y:  rhs,
-			Op: tk.Typ,
+			X:     lhs,
+			Y:     rhs,
+			Op:    tk.Typ,
+			OpPos: tk.Pos,
 		}
+		p.close(mark, lhs)
 	}
 }
 
@@ -301,16 +650,21 @@ func (p *Parser) parseExpr(lhs ast.Expr, minPrec token.Precedence) ast.Expr {
 func (p *Parser) parseAssignExpr() ast.Expr {
 	switch firstToken := p.scanner.CurrToken(); firstToken.Typ {
 	case token.IDENTIFIER:
+		var mark = p.open()
 		var firstIdent = ast.Ident{Name: firstToken.Lit, Pos: firstToken.Pos}
 		p.proceed()
 		if t := p.scanner.CurrToken(); t.Typ == token.ASSIGN && t.Lit == "=" {
 			p.proceed()
-			return ast.AssignExpr{
+			var assign = ast.AssignExpr{
 				Name: firstIdent.Name,
 				Expr: p.parseExpr(nil, token.LowestPrecedence),
 			}
+			p.close(mark, assign)
+			return assign
 		} else {
-			return p.parseExpr(ast.Column{Name: firstIdent}, token.LowestPrecedence)
+			var expr = p.parseIdentExpr(firstToken)
+			p.close(mark, expr)
+			return p.parseExpr(expr, token.LowestPrecedence)
 		}
 
 	default:
@@ -345,67 +699,199 @@ func (p *Parser) expectType(typ token.Token) scanner.Token {
 	panic(ParseError{fmt.Errorf("expected %s, got %s", typ, t)})
 }
 
-func (p *Parser) parseExprList() ast.ExprList {
-	var list ast.ExprList
+func (p *Parser) parseExprList() (list ast.ExprList) {
+	var mark = p.open()
+	defer func() {
+		p.close(mark, list)
+		p.itemIdx += len(list.Items)
+	}()
 
 	switch t1 := p.scanner.CurrToken(); t1.Typ {
-	case token.LBRACK:
-		p.proceed()
-		for {
-			p.checkErr(p.skipOptionalNewlines())
+	case token.LBRACK, token.LBRACE:
+		return p.parseTuple(func(start scanner.Pos, i int) {
+			p.addSpan(ast.NodePath{Transform: p.transformIdx, Item: p.itemIdx + i}, start)
+		})
+	default:
+		var assign = p.parseAssignExpr()
+		p.addSpan(ast.NodePath{Transform: p.transformIdx, Item: p.itemIdx + len(list.Items)}, t1.Pos)
+		list.Items = append(list.Items, assign)
+		return list
+	}
+}
+
+// parseTuple parses the items of a list in brackets, [a, b], or braces,
+// {a, b}. If span is not nil, it is called after each item with the item's
+// start and index.
+func (p *Parser) parseTuple(span func(start scanner.Pos, i int)) (list ast.ExprList) {
+	var end = token.RBRACK
+	if p.scanner.CurrToken().Typ == token.LBRACE {
+		end = token.RBRACE
+		list.Braces = true
+	}
+	p.proceed()
+	for {
+		p.checkErr(p.skipOptionalNewlines())
+		switch tk := p.scanner.CurrToken(); tk.Typ {
+		case end:
+			p.proceed()
+			return list
+		case token.EOF:
+			return list
+		default:
+			var start = tk.Pos
+			var assign = p.parseAssignExpr()
+			if span != nil {
+				span(start, len(list.Items))
+			}
+			list.Items = append(list.Items, assign)
+
 			switch tk := p.scanner.CurrToken(); tk.Typ {
-			case token.RBRACK:
+			case token.COMMA:
+				p.proceed()
+			case token.NEWLINE:
+				p.checkErr(p.skipOptionalNewlines())
+			case end:
 				p.proceed()
-				return list
-			case token.EOF:
 				return list
 			default:
-				var assign = p.parseAssignExpr()
-				list.Items = append(list.Items, assign)
-
-				switch tk := p.scanner.CurrToken(); tk.Typ {
-				case token.COMMA:
-					p.proceed()
-				case token.NEWLINE:
-					p.checkErr(p.skipOptionalNewlines())
-				case token.RBRACK:
-					p.proceed()
-				default:
-					panic(ParseError{fmt.Errorf("unexpected token %s", tk)})
-				}
+				panic(ParseError{fmt.Errorf("unexpected token %s", tk)})
 			}
 		}
-	default:
-		var assign = p.parseAssignExpr()
-		list.Items = append(list.Items, assign)
-		return list
 	}
 }
 
 func (p *Parser) parseDeriveTransform() ast.Node {
-	var list = ast.ExprList{
-		Items: nil,
-	}
+	p.expect(token.IDENTIFIER, stdlib.Derive.Name)
+	p.proceed()
+
+	return ast.DeriveTransform{List: p.parseExprList()}
+}
+
+func (p *Parser) parseSelectTransform() ast.Node {
+	p.expect(token.IDENTIFIER, stdlib.Select.Name)
+	p.proceed()
 
-	p.expect(token.IDENTIFIER, "derive")
+	return ast.SelectTransform{List: p.parseExprList()}
+}
+
+func (p *Parser) parseSortTransform() ast.Node {
+	p.expect(token.IDENTIFIER, stdlib.Sort.Name)
 	p.proceed()
 
-	var items = p.parseExprList()
-	list.Items = items.Items
+	return ast.SortTransform{List: p.parseExprList()}
+}
+
+func (p *Parser) parseFilterTransform() ast.Node {
+	p.expect(token.IDENTIFIER, stdlib.Filter.Name)
+	p.proceed()
 
-	return ast.DeriveTransform{List: list}
+	return ast.FilterTransform{Expr: p.parseExpr(nil, token.LowestPrecedence)}
 }
 
-func (p *Parser) parseSelectTransform() ast.Node {
-	var list = ast.ExprList{
-		Items: nil,
+func (p *Parser) parseTakeTransform() ast.Node {
+	p.expect(token.IDENTIFIER, stdlib.Take.Name)
+	p.proceed()
+
+	// a number of rows, or a range of them, e.g. 5..10 or ..10
+	if p.scanner.CurrToken().Typ == token.RANGE {
+		return ast.TakeTransform{Expr: p.parseRange()}
 	}
+	return ast.TakeTransform{Expr: p.parseRangeArg(p.parseExpr(nil, token.LowestPrecedence))}
+}
 
-	p.expect(token.IDENTIFIER, "select")
+func (p *Parser) parseWindowTransform() ast.Node {
+	p.expect(token.IDENTIFIER, stdlib.Window.Name)
 	p.proceed()
 
-	var items = p.parseExprList()
-	list.Items = items.Items
+	var window = ast.WindowTransform{}
+	for p.scanner.CurrToken().Typ == token.IDENTIFIER {
+		var name = p.scanner.CurrToken()
+		var param, ok = stdlib.Window.Lookup(name.Lit)
+		if !ok {
+			panic(ParseError{fmt.Errorf("window has no parameter %s at %d", name.Lit, name.Pos)})
+		}
+		p.proceed()
+		p.expectType(token.COLON)
+		p.proceed()
+
+		var value ast.Expr
+		if param.Type == "range" {
+			value = p.parseRange()
+		} else {
+			value = p.parsePrimaryExpr()
+		}
+		window.Named = append(window.Named, ast.NamedArg{
+			Name:  ast.Ident{Name: name.Lit, Pos: name.Pos},
+			Value: value,
+		})
+	}
+	window.Pipeline = p.parsePipeline()
+	return window
+}
 
-	return ast.SelectTransform{List: list}
+func (p *Parser) parseGroupTransform() ast.Node {
+	p.expect(token.IDENTIFIER, stdlib.Group.Name)
+	p.proceed()
+
+	var by = p.parseExprList()
+	return ast.GroupTransform{By: by, Pipeline: p.parsePipeline()}
+}
+
+// parsePipeline parses the pipeline nested in a transform, e.g.
+// (sort date | derive rolling = average value), whose transforms are
+// separated by pipes or newlines.
+func (p *Parser) parsePipeline() []ast.Node {
+	p.expectType(token.LPAREN)
+	p.proceed()
+
+	var nodes []ast.Node
+	for {
+		p.checkErr(p.skipOptionalNewlines())
+		switch t := p.scanner.CurrToken(); t.Typ {
+		case token.RPAREN:
+			// the end of the pipeline holds the comments before it
+			p.nestedIdx++
+			p.addSpanAt(ast.NodePath{Transform: p.transformIdx, Item: -1, Nested: p.nestedIdx}, t.Pos, t.Pos)
+			p.proceed()
+			return nodes
+		case token.PIPE:
+			p.proceed()
+		case token.EOF:
+			p.expectType(token.RPAREN)
+		default:
+			p.nestedIdx++
+			var path = ast.NodePath{Transform: p.transformIdx, Item: -1, Nested: p.nestedIdx}
+			var mark = p.open()
+			var node = p.parseTransform(0)
+			p.close(mark, node)
+			p.addSpan(path, t.Pos)
+			nodes = append(nodes, node)
+		}
+	}
+}
+
+// parseRange parses a range, e.g. -3..0, ..0 or 1..
+func (p *Parser) parseRange() ast.Expr {
+	var mark = p.open()
+	var start ast.Expr
+	if p.scanner.CurrToken().Typ != token.RANGE {
+		start = p.parsePrimaryExpr()
+	}
+	return p.parseRangeEnd(mark, start)
+}
+
+// parseRangeEnd parses the rest of a range whose start, or nil, has been
+// parsed since mark.
+func (p *Parser) parseRangeEnd(mark int, start ast.Expr) ast.Expr {
+	var r = ast.RangeExpr{Start: start}
+	p.expectType(token.RANGE)
+	p.proceed()
+
+	switch p.scanner.CurrToken().Typ {
+	case token.INTEGER, token.FLOAT, token.ADD, token.SUB, token.INTERVAL,
+		token.DATE, token.TIME, token.TIMESTAMP, token.LPAREN:
+		r.End = p.parsePrimaryExpr()
+	}
+	p.close(mark, r)
+	return r
 }
@@ -133,6 +133,94 @@ func TestParser(tt *testing.T) {
 				},
 			},
 		},
+		{
+			src: "select [column1, column2]\nderive x = 5",
+			want: &ast.Root{
+				Transforms: []ast.Node{
+					ast.SelectTransform{
+						List: ast.ExprList{
+							Items: []ast.Expr{
+								ast.Column{Name: ast.Ident{Name: "column1", Pos: IgnorePos}},
+								ast.Column{Name: ast.Ident{Name: "column2", Pos: IgnorePos}},
+							},
+						},
+					},
+					ast.DeriveTransform{
+						List: ast.ExprList{
+							Items: []ast.Expr{
+								ast.AssignExpr{Name: "x", Expr: ast.Integer{Value: 5}},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			src: "\nprql dialect:sqlite\nfrom table1\nderive x = true\ntake 10",
+			want: &ast.Root{
+				Header: &ast.QueryHeader{
+					Args: []ast.NamedArg{
+						{
+							Name:  ast.Ident{Name: "dialect", Pos: IgnorePos},
+							Value: ast.Column{Name: ast.Ident{Name: "sqlite", Pos: IgnorePos}},
+						},
+					},
+				},
+				Transforms: []ast.Node{
+					ast.FromTransform{
+						Table: ast.Ident{Name: "table1", Pos: IgnorePos},
+					},
+					ast.DeriveTransform{
+						List: ast.ExprList{
+							Items: []ast.Expr{
+								ast.AssignExpr{Name: "x", Expr: ast.Boolean{Value: true}},
+							},
+						},
+					},
+					ast.TakeTransform{Expr: ast.Integer{Value: 10}},
+				},
+			},
+		},
+		{
+			src: "take 5..10\ntake ..3\ntake 2..",
+			want: &ast.Root{
+				Transforms: []ast.Node{
+					ast.TakeTransform{Expr: ast.RangeExpr{Start: ast.Integer{Value: 5}, End: ast.Integer{Value: 10}}},
+					ast.TakeTransform{Expr: ast.RangeExpr{End: ast.Integer{Value: 3}}},
+					ast.TakeTransform{Expr: ast.RangeExpr{Start: ast.Integer{Value: 2}}},
+				},
+			},
+		},
+		{
+			src: `filter a > 1 or b == 2 and c`,
+			want: &ast.Root{
+				Transforms: []ast.Node{
+					ast.FilterTransform{
+						Expr: ast.BinaryExpr{
+							OpPos: IgnorePos,
+							X: ast.BinaryExpr{
+								OpPos: IgnorePos,
+								X:     ast.Column{Name: ast.Ident{Name: "a", Pos: IgnorePos}},
+								Y:     ast.Integer{Value: 1},
+								Op:    token.GTR,
+							},
+							Y: ast.BinaryExpr{
+								OpPos: IgnorePos,
+								X: ast.BinaryExpr{
+									OpPos: IgnorePos,
+									X:     ast.Column{Name: ast.Ident{Name: "b", Pos: IgnorePos}},
+									Y:     ast.Integer{Value: 2},
+									Op:    token.EQL,
+								},
+								Y:  ast.Column{Name: ast.Ident{Name: "c", Pos: IgnorePos}},
+								Op: token.AND,
+							},
+							Op: token.OR,
+						},
+					},
+				},
+			},
+		},
 		{
 			src: `derive x = 5`,
 			want: &ast.Root{
@@ -152,8 +240,8 @@ func TestParser(tt *testing.T) {
 			select [
 			  1, 1+2, 1 * 2, # 2 expressions in one line
 			  +3 + -2.1, # signed numbers
-			  expr1 = 1 + 2 * 3 * 4 + 5 # == 1 + ((2 * (3 * 4)) + 5),
-			  expr2 = 1 * 2 + 3 + 4 * 5 # == (1 * 2) + (3 + (4 * 5)),
+			  expr1 = 1 + 2 * 3 * 4 + 5 # == (1 + ((2 * 3) * 4)) + 5,
+			  expr2 = 1 * 2 + 3 + 4 * 5 # == ((1 * 2) + 3) + (4 * 5),
 			]
 			`,
 			want: &ast.Root{
@@ -163,57 +251,68 @@ func TestParser(tt *testing.T) {
 							Items: []ast.Expr{
 								ast.Integer{Value: 1},
 								ast.BinaryExpr{
-									X:  ast.Integer{Value: 1},
-									Y:  ast.Integer{Value: 2},
-									Op: token.ADD,
+									OpPos: IgnorePos,
+									X:     ast.Integer{Value: 1},
+									Y:     ast.Integer{Value: 2},
+									Op:    token.ADD,
 								},
 								ast.BinaryExpr{
-									X:  ast.Integer{Value: 1},
-									Y:  ast.Integer{Value: 2},
-									Op: token.MUL,
+									OpPos: IgnorePos,
+									X:     ast.Integer{Value: 1},
+									Y:     ast.Integer{Value: 2},
+									Op:    token.MUL,
 								},
 								ast.BinaryExpr{
-									X:  ast.UnaryExpr{X: ast.Integer{Value: 3}, Op: token.ADD},
-									Y:  ast.UnaryExpr{X: ast.Float{Value: 2.1}, Op: token.SUB},
-									Op: token.ADD,
+									OpPos: IgnorePos,
+									X:     ast.UnaryExpr{X: ast.Integer{Value: 3}, Op: token.ADD},
+									Y:     ast.UnaryExpr{X: ast.Float{Value: 2.1}, Op: token.SUB},
+									Op:    token.ADD,
 								},
 								ast.AssignExpr{
 									Name: "expr1",
 									Expr: ast.BinaryExpr{
-										X: ast.Integer{Value: 1},
-										Y: ast.BinaryExpr{
-											X: ast.BinaryExpr{
-												X: ast.Integer{Value: 2},
-												Y: ast.BinaryExpr{
-													X:  ast.Integer{Value: 3},
-													Y:  ast.Integer{Value: 4},
-													Op: token.MUL,
+										OpPos: IgnorePos,
+										X: ast.BinaryExpr{
+											OpPos: IgnorePos,
+											X:     ast.Integer{Value: 1},
+											Y: ast.BinaryExpr{
+												OpPos: IgnorePos,
+												X: ast.BinaryExpr{
+													OpPos: IgnorePos,
+													X:     ast.Integer{Value: 2},
+													Y:     ast.Integer{Value: 3},
+													Op:    token.MUL,
 												},
+												Y:  ast.Integer{Value: 4},
 												Op: token.MUL,
 											},
-											Y:  ast.Integer{Value: 5},
 											Op: token.ADD,
 										},
+										Y:  ast.Integer{Value: 5},
 										Op: token.ADD,
 									},
 								},
 								ast.AssignExpr{
 									Name: "expr2",
 									Expr: ast.BinaryExpr{
+										OpPos: IgnorePos,
 										X: ast.BinaryExpr{
-											X:  ast.Integer{Value: 1},
-											Y:  ast.Integer{Value: 2},
-											Op: token.MUL,
-										},
-										Y: ast.BinaryExpr{
-											X: ast.Integer{Value: 3},
-											Y: ast.BinaryExpr{
-												X:  ast.Integer{Value: 4},
-												Y:  ast.Integer{Value: 5},
-												Op: token.MUL,
+											OpPos: IgnorePos,
+											X: ast.BinaryExpr{
+												OpPos: IgnorePos,
+												X:     ast.Integer{Value: 1},
+												Y:     ast.Integer{Value: 2},
+												Op:    token.MUL,
 											},
+											Y:  ast.Integer{Value: 3},
 											Op: token.ADD,
 										},
+										Y: ast.BinaryExpr{
+											OpPos: IgnorePos,
+											X:     ast.Integer{Value: 4},
+											Y:     ast.Integer{Value: 5},
+											Op:    token.MUL,
+										},
 										Op: token.ADD,
 									},
 								},
@@ -243,44 +342,51 @@ func TestParser(tt *testing.T) {
 							Items: []ast.Expr{
 								ast.Column{Name: ast.Ident{Name: "column1", Pos: IgnorePos}},
 								ast.BinaryExpr{
-									X:  ast.Column{Name: ast.Ident{Name: "x", Pos: IgnorePos}},
-									Y:  ast.Integer{Value: 1},
-									Op: token.SUB,
+									OpPos: IgnorePos,
+									X:     ast.Column{Name: ast.Ident{Name: "x", Pos: IgnorePos}},
+									Y:     ast.Integer{Value: 1},
+									Op:    token.SUB,
 								},
 								ast.BinaryExpr{
-									X:  ast.Integer{Value: 1},
-									Y:  ast.Column{Name: ast.Ident{Name: "x", Pos: IgnorePos}},
-									Op: token.SUB,
+									OpPos: IgnorePos,
+									X:     ast.Integer{Value: 1},
+									Y:     ast.Column{Name: ast.Ident{Name: "x", Pos: IgnorePos}},
+									Op:    token.SUB,
 								},
 								ast.ParenExpr{
 									X: ast.Integer{Value: 1},
 								},
 								ast.ParenExpr{
 									X: ast.BinaryExpr{
-										X:  ast.Integer{Value: 1},
-										Y:  ast.Integer{Value: 2},
-										Op: token.ADD,
+										OpPos: IgnorePos,
+										X:     ast.Integer{Value: 1},
+										Y:     ast.Integer{Value: 2},
+										Op:    token.ADD,
 									},
 								},
 								ast.BinaryExpr{
-									X:  ast.Column{Name: ast.Ident{Name: "y", Pos: IgnorePos}},
-									Y:  ast.ParenExpr{X: ast.Integer{Value: 1}},
-									Op: token.ADD,
+									OpPos: IgnorePos,
+									X:     ast.Column{Name: ast.Ident{Name: "y", Pos: IgnorePos}},
+									Y:     ast.ParenExpr{X: ast.Integer{Value: 1}},
+									Op:    token.ADD,
 								},
 								ast.BinaryExpr{
-									X:  ast.ParenExpr{X: ast.Integer{Value: 1}},
-									Y:  ast.Column{Name: ast.Ident{Name: "x", Pos: IgnorePos}},
-									Op: token.ADD,
+									OpPos: IgnorePos,
+									X:     ast.ParenExpr{X: ast.Integer{Value: 1}},
+									Y:     ast.Column{Name: ast.Ident{Name: "x", Pos: IgnorePos}},
+									Op:    token.ADD,
 								},
 								ast.AssignExpr{
 									Name: "z",
 									Expr: ast.ParenExpr{
 										X: ast.BinaryExpr{
+											OpPos: IgnorePos,
 											X: ast.ParenExpr{
 												X: ast.BinaryExpr{
-													X:  ast.Column{Name: ast.Ident{Name: "z", Pos: IgnorePos}},
-													Y:  ast.Integer{Value: 2},
-													Op: token.MUL,
+													OpPos: IgnorePos,
+													X:     ast.Column{Name: ast.Ident{Name: "z", Pos: IgnorePos}},
+													Y:     ast.Integer{Value: 2},
+													Op:    token.MUL,
 												},
 											},
 											Y:  ast.Integer{Value: 1},
@@ -294,6 +400,330 @@ func TestParser(tt *testing.T) {
 				},
 			},
 		},
+		{
+			// calls of standard library functions, and columns named like
+			// functions
+			src: "derive [r = round 2 price, s = sum amount + 1, t = trim chars:\"x\" (name)]\nselect [sum, max]",
+			want: &ast.Root{
+				Transforms: []ast.Node{
+					ast.DeriveTransform{
+						List: ast.ExprList{
+							Items: []ast.Expr{
+								ast.AssignExpr{
+									Name: "r",
+									Expr: ast.CallExpr{
+										Name: ast.Ident{Name: "round", Pos: 12},
+										Args: []ast.Expr{
+											ast.Integer{Value: 2},
+											ast.Column{Name: ast.Ident{Name: "price", Pos: 20}},
+										},
+									},
+								},
+								ast.AssignExpr{
+									Name: "s",
+									Expr: ast.BinaryExpr{
+										OpPos: 42,
+										X: ast.CallExpr{
+											Name: ast.Ident{Name: "sum", Pos: 31},
+											Args: []ast.Expr{
+												ast.Column{Name: ast.Ident{Name: "amount", Pos: 35}},
+											},
+										},
+										Y:  ast.Integer{Value: 1},
+										Op: token.ADD,
+									},
+								},
+								ast.AssignExpr{
+									Name: "t",
+									Expr: ast.CallExpr{
+										Name: ast.Ident{Name: "trim", Pos: 51},
+										Args: []ast.Expr{
+											ast.ParenExpr{X: ast.Column{Name: ast.Ident{Name: "name", Pos: 67}}},
+										},
+										Named: []ast.NamedArg{
+											{
+												Name:  ast.Ident{Name: "chars", Pos: 56},
+												Value: ast.String{Value: `"x"`},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					ast.SelectTransform{
+						List: ast.ExprList{
+							Items: []ast.Expr{
+								ast.Column{Name: ast.Ident{Name: "sum", Pos: 82}},
+								ast.Column{Name: ast.Ident{Name: "max", Pos: 87}},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			src: "select {a = x, b}\nderive t = [1, {c}]",
+			want: &ast.Root{
+				Transforms: []ast.Node{
+					ast.SelectTransform{
+						List: ast.ExprList{
+							Items: []ast.Expr{
+								ast.AssignExpr{Name: "a", Expr: ast.Column{Name: ast.Ident{Name: "x", Pos: 12}}},
+								ast.Column{Name: ast.Ident{Name: "b", Pos: 15}},
+							},
+							Braces: true,
+						},
+					},
+					ast.DeriveTransform{
+						List: ast.ExprList{
+							Items: []ast.Expr{
+								ast.AssignExpr{
+									Name: "t",
+									Expr: ast.Array{
+										Items: []ast.Expr{
+											ast.Integer{Value: 1},
+											ast.ExprList{
+												Items:  []ast.Expr{ast.Column{Name: ast.Ident{Name: "c", Pos: 34}}},
+												Braces: true,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			src: "filter in [\"US\", \"CA\"] country\nderive [a = in 1..10 x, b = in ..(y) x]",
+			want: &ast.Root{
+				Transforms: []ast.Node{
+					ast.FilterTransform{
+						Expr: ast.CallExpr{
+							Name: ast.Ident{Name: "in", Pos: 7},
+							Args: []ast.Expr{
+								ast.Array{Items: []ast.Expr{ast.String{Value: `"US"`}, ast.String{Value: `"CA"`}}},
+								ast.Column{Name: ast.Ident{Name: "country", Pos: 23}},
+							},
+						},
+					},
+					ast.DeriveTransform{
+						List: ast.ExprList{
+							Items: []ast.Expr{
+								ast.AssignExpr{
+									Name: "a",
+									Expr: ast.CallExpr{
+										Name: ast.Ident{Name: "in", Pos: 43},
+										Args: []ast.Expr{
+											ast.RangeExpr{Start: ast.Integer{Value: 1}, End: ast.Integer{Value: 10}},
+											ast.Column{Name: ast.Ident{Name: "x", Pos: 52}},
+										},
+									},
+								},
+								ast.AssignExpr{
+									Name: "b",
+									Expr: ast.CallExpr{
+										Name: ast.Ident{Name: "in", Pos: 59},
+										Args: []ast.Expr{
+											ast.RangeExpr{End: ast.ParenExpr{X: ast.Column{Name: ast.Ident{Name: "y", Pos: 65}}}},
+											ast.Column{Name: ast.Ident{Name: "x", Pos: 68}},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			src: "derive x = (amount | round 2)\nfilter (a + 1 | in 1..3)",
+			want: &ast.Root{
+				Transforms: []ast.Node{
+					ast.DeriveTransform{
+						List: ast.ExprList{
+							Items: []ast.Expr{
+								ast.AssignExpr{
+									Name: "x",
+									Expr: ast.ParenExpr{
+										X: ast.PipeExpr{
+											X: ast.Column{Name: ast.Ident{Name: "amount", Pos: 12}},
+											Calls: []ast.CallExpr{
+												{Name: ast.Ident{Name: "round", Pos: 21}, Args: []ast.Expr{ast.Integer{Value: 2}}},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					ast.FilterTransform{
+						Expr: ast.ParenExpr{
+							X: ast.PipeExpr{
+								X: ast.BinaryExpr{
+									X:     ast.Column{Name: ast.Ident{Name: "a", Pos: 38}},
+									Y:     ast.Integer{Value: 1},
+									Op:    token.ADD,
+									OpPos: 40,
+								},
+								Calls: []ast.CallExpr{
+									{
+										Name: ast.Ident{Name: "in", Pos: 46},
+										Args: []ast.Expr{ast.RangeExpr{Start: ast.Integer{Value: 1}, End: ast.Integer{Value: 3}}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			src: "derive [f = x -> x + 1, g = (a b -> a * b)]",
+			want: &ast.Root{
+				Transforms: []ast.Node{
+					ast.DeriveTransform{
+						List: ast.ExprList{
+							Items: []ast.Expr{
+								ast.AssignExpr{
+									Name: "f",
+									Expr: ast.FuncLit{
+										Params: []ast.Ident{{Name: "x", Pos: 12}},
+										Body: ast.BinaryExpr{
+											X:     ast.Column{Name: ast.Ident{Name: "x", Pos: 17}},
+											Y:     ast.Integer{Value: 1},
+											Op:    token.ADD,
+											OpPos: 19,
+										},
+									},
+								},
+								ast.AssignExpr{
+									Name: "g",
+									Expr: ast.ParenExpr{
+										X: ast.FuncLit{
+											Params: []ast.Ident{{Name: "a", Pos: 29}, {Name: "b", Pos: 31}},
+											Body: ast.BinaryExpr{
+												X:     ast.Column{Name: ast.Ident{Name: "a", Pos: 36}},
+												Y:     ast.Column{Name: ast.Ident{Name: "b", Pos: 40}},
+												Op:    token.MUL,
+												OpPos: 38,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			src: "derive g = case [\n  a > 1 => \"x\",\n  true => b\n]",
+			want: &ast.Root{
+				Transforms: []ast.Node{
+					ast.DeriveTransform{
+						List: ast.ExprList{
+							Items: []ast.Expr{
+								ast.AssignExpr{
+									Name: "g",
+									Expr: ast.CaseExpr{
+										Case: 11,
+										Arms: []ast.CaseArm{
+											{
+												Cond: ast.BinaryExpr{
+													X:     ast.Column{Name: ast.Ident{Name: "a", Pos: 20}},
+													Y:     ast.Integer{Value: 1},
+													Op:    token.GTR,
+													OpPos: 22,
+												},
+												Value: ast.String{Value: `"x"`},
+											},
+											{
+												Cond:  ast.Boolean{Value: true},
+												Value: ast.Column{Name: ast.Ident{Name: "b", Pos: 44}},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			src: "group [dept] (\n  sort date\n  window rows:-3..0 (derive r = average value)\n)\nwindow expanding:true range:..5 (derive t = sum value | sort t)",
+			want: &ast.Root{
+				Transforms: []ast.Node{
+					ast.GroupTransform{
+						By: ast.ExprList{Items: []ast.Expr{ast.Column{Name: ast.Ident{Name: "dept", Pos: 7}}}},
+						Pipeline: []ast.Node{
+							ast.SortTransform{
+								List: ast.ExprList{Items: []ast.Expr{ast.Column{Name: ast.Ident{Name: "date", Pos: 22}}}},
+							},
+							ast.WindowTransform{
+								Named: []ast.NamedArg{
+									{
+										Name: ast.Ident{Name: "rows", Pos: 36},
+										Value: ast.RangeExpr{
+											Start: ast.UnaryExpr{X: ast.Integer{Value: 3}, Op: token.SUB},
+											End:   ast.Integer{Value: 0},
+										},
+									},
+								},
+								Pipeline: []ast.Node{
+									ast.DeriveTransform{
+										List: ast.ExprList{
+											Items: []ast.Expr{
+												ast.AssignExpr{
+													Name: "r",
+													Expr: ast.CallExpr{
+														Name: ast.Ident{Name: "average", Pos: 59},
+														Args: []ast.Expr{ast.Column{Name: ast.Ident{Name: "value", Pos: 67}}},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					ast.WindowTransform{
+						Named: []ast.NamedArg{
+							{
+								Name:  ast.Ident{Name: "expanding", Pos: 83},
+								Value: ast.Boolean{Value: true},
+							},
+							{
+								Name:  ast.Ident{Name: "range", Pos: 98},
+								Value: ast.RangeExpr{End: ast.Integer{Value: 5}},
+							},
+						},
+						Pipeline: []ast.Node{
+							ast.DeriveTransform{
+								List: ast.ExprList{
+									Items: []ast.Expr{
+										ast.AssignExpr{
+											Name: "t",
+											Expr: ast.CallExpr{
+												Name: ast.Ident{Name: "sum", Pos: 120},
+												Args: []ast.Expr{ast.Column{Name: ast.Ident{Name: "value", Pos: 124}}},
+											},
+										},
+									},
+								},
+							},
+							ast.SortTransform{
+								List: ast.ExprList{Items: []ast.Expr{ast.Column{Name: ast.Ident{Name: "t", Pos: 137}}}},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/scanner"
+	"github.com/siadat/prql-parser/stdlib"
+	"github.com/siadat/prql-parser/token"
+)
+
+// TransformFunc parses a transform, starting at its name. Errors are
+// reported with Parser.Errorf, which stops parsing.
+type TransformFunc func(p *Parser) ast.Node
+
+var (
+	transformsMu sync.RWMutex
+	transforms   = map[string]TransformFunc{}
+)
+
+// the standard library transforms are added in init, as they may contain
+// nested transforms that are parsed with the transforms table
+func init() {
+	transforms[stdlib.From.Name] = (*Parser).parseFromTransform
+	transforms[stdlib.Select.Name] = (*Parser).parseSelectTransform
+	transforms[stdlib.Derive.Name] = (*Parser).parseDeriveTransform
+	transforms[stdlib.Filter.Name] = (*Parser).parseFilterTransform
+	transforms[stdlib.Sort.Name] = (*Parser).parseSortTransform
+	transforms[stdlib.Take.Name] = (*Parser).parseTakeTransform
+	transforms[stdlib.Window.Name] = (*Parser).parseWindowTransform
+	transforms[stdlib.Group.Name] = (*Parser).parseGroupTransform
+}
+
+// RegisterTransform makes the parsers parse transforms starting with the
+// keyword name with parse. It returns an error if name is not an
+// identifier, or if a transform with the same name is already registered,
+// including the transforms of the standard library.
+func RegisterTransform(name string, parse TransformFunc) error {
+	if parse == nil {
+		return fmt.Errorf("transform %q has no parse function", name)
+	}
+	var t, err = scanner.NewScanner(strings.NewReader(name)).NextToken()
+	if err != nil || t.Typ != token.IDENTIFIER || t.Lit != name || name == "prql" {
+		return fmt.Errorf("invalid transform name %q", name)
+	}
+
+	transformsMu.Lock()
+	defer transformsMu.Unlock()
+	if _, ok := transforms[name]; ok {
+		return fmt.Errorf("transform %q is already registered", name)
+	}
+	transforms[name] = parse
+	return nil
+}
+
+func lookupTransform(name string) (TransformFunc, bool) {
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+	var parse, ok = transforms[name]
+	return parse, ok
+}
+
+// ListTransform parses a transform whose argument is a list, e.g.
+// mask_pii [email, phone], into an ast.CustomTransform.
+func ListTransform(p *Parser) ast.Node {
+	var name = p.ParseKeyword()
+	return ast.CustomTransform{Name: name, List: p.ParseList()}
+}
+
+// Errorf stops parsing with the error message at pos, which Parse returns as
+// a ParseError.
+func (p *Parser) Errorf(pos scanner.Pos, format string, args ...interface{}) {
+	panic(ParseError{fmt.Errorf("%s at %d", fmt.Sprintf(format, args...), pos)})
+}
+
+// ParseKeyword consumes the identifier that a transform starts with.
+func (p *Parser) ParseKeyword() ast.Ident {
+	var t = p.expectType(token.IDENTIFIER)
+	p.proceed()
+	return ast.Ident{Name: t.Lit, Pos: t.Pos}
+}
+
+// ParseList parses an expression, or a list of expressions in brackets or
+// braces.
+func (p *Parser) ParseList() ast.ExprList {
+	return p.parseExprList()
+}
@@ -0,0 +1,107 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/scanner"
+)
+
+func init() {
+	if err := parser.RegisterTransform("mask_pii", parser.ListTransform); err != nil {
+		panic(err)
+	}
+	if err := parser.RegisterTransform("keep_one", parseKeepOne); err != nil {
+		panic(err)
+	}
+	if err := parser.RegisterTransform("broken", func(p *parser.Parser) ast.Node { panic("broken transform") }); err != nil {
+		panic(err)
+	}
+}
+
+// parseKeepOne parses a transform that takes a single column.
+func parseKeepOne(p *parser.Parser) ast.Node {
+	var name = p.ParseKeyword()
+	var list = p.ParseList()
+	if len(list.Items) != 1 {
+		p.Errorf(name.Pos, "%s expects 1 column, got %d", name.Name, len(list.Items))
+	}
+	return ast.CustomTransform{Name: name, List: list}
+}
+
+func TestCustomTransform(tt *testing.T) {
+	var src = "from users\nmask_pii [email, phone]\ntake 10"
+	var want = &ast.Root{
+		Transforms: []ast.Node{
+			ast.FromTransform{Table: ast.Ident{Name: "users", Pos: IgnorePos}},
+			ast.CustomTransform{
+				Name: ast.Ident{Name: "mask_pii", Pos: 11},
+				List: ast.ExprList{Items: []ast.Expr{
+					ast.Column{Name: ast.Ident{Name: "email", Pos: 21}},
+					ast.Column{Name: ast.Ident{Name: "phone", Pos: 28}},
+				}},
+			},
+			ast.TakeTransform{Expr: ast.Integer{Value: 10}},
+		},
+	}
+
+	var got, err = parser.NewParser().Parse(strings.NewReader(src))
+	if err != nil {
+		tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", src, err)
+	}
+	var cmpOpt = cmp.FilterValues(func(p1, p2 scanner.Pos) bool { return p1 == IgnorePos || p2 == IgnorePos || p1 == p2 }, cmp.Ignore())
+	if diff := cmp.Diff(want, got, cmpOpt); diff != "" {
+		tt.Fatalf("mismatching results\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", src, diff)
+	}
+}
+
+func TestRegisterTransformErrors(tt *testing.T) {
+	var testCases = []struct {
+		name  string
+		parse parser.TransformFunc
+		want  string
+	}{
+		{name: "derive", parse: parser.ListTransform, want: `transform "derive" is already registered`},
+		{name: "mask_pii", parse: parser.ListTransform, want: `transform "mask_pii" is already registered`},
+		{name: "mask-pii", parse: parser.ListTransform, want: `invalid transform name "mask-pii"`},
+		{name: "true", parse: parser.ListTransform, want: `invalid transform name "true"`},
+		{name: "prql", parse: parser.ListTransform, want: `invalid transform name "prql"`},
+		{name: "redact", want: `transform "redact" has no parse function`},
+	}
+
+	for _, tc := range testCases {
+		var err = parser.RegisterTransform(tc.name, tc.parse)
+		if err == nil {
+			tt.Fatalf("expected an error registering %q, got nil", tc.name)
+		}
+		if diff := cmp.Diff(tc.want, err.Error()); diff != "" {
+			tt.Fatalf("mismatching errors\nname: %s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.name, diff)
+		}
+	}
+}
+
+func TestCustomTransformErrors(tt *testing.T) {
+	var src = "from users\nkeep_one [email, phone]"
+	var _, err = parser.NewParser().Parse(strings.NewReader(src))
+	if err == nil {
+		tt.Fatalf("expected an error, got nil\nsrc:\n%s", src)
+	}
+	if _, ok := err.(parser.ParseError); !ok {
+		tt.Fatalf("expected a ParseError, got %T", err)
+	}
+	if diff := cmp.Diff("keep_one expects 1 column, got 2 at 11", err.Error()); diff != "" {
+		tt.Fatalf("mismatching errors\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", src, diff)
+	}
+
+	// other panics are not syntax errors and are not recovered
+	defer func() {
+		if r := recover(); r != "broken transform" {
+			tt.Fatalf("expected the panic of the transform, got %v", r)
+		}
+	}()
+	parser.NewParser().Parse(strings.NewReader("from users\nbroken"))
+	tt.Fatal("expected a panic")
+}
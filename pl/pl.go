@@ -0,0 +1,322 @@
+// Package pl exports the AST in the JSON shape of the PL (pipelined
+// language) AST of the reference PRQL compiler, as printed by
+// `prqlc debug` for prql-compiler 0.8. It covers the subset of PRQL this
+// parser supports, so that the output of both parsers can be diffed.
+//
+// The query is a list of statements holding a single Main statement,
+// preceded by a QueryDef statement when the query has a header. Each
+// transform is a FuncCall, and pipelines of more than one transform are
+// wrapped in a Pipeline. Parentheses are not represented, and assignments
+// become the alias of the assigned expression.
+package pl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/token"
+)
+
+type exportError struct {
+	err error
+}
+
+// object is a JSON object. Enums are encoded as objects with a single
+// member named after the variant, as serde does by default.
+type object = map[string]interface{}
+
+// Marshal returns the PL JSON of root.
+func Marshal(root *ast.Root) ([]byte, error) {
+	var stmts, err = Export(root)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(stmts)
+}
+
+// Export returns the PL statements of root as values that encoding/json
+// can marshal.
+func Export(root *ast.Root) (retStmts []interface{}, retErr error) {
+	defer func() {
+		var r = recover()
+		if err, ok := r.(exportError); ok {
+			retErr = err.err
+		} else if r != nil {
+			panic(r)
+		}
+	}()
+
+	var stmts = []interface{}{}
+	if root.Header != nil {
+		stmts = append(stmts, queryDef(*root.Header))
+	}
+	if len(root.Transforms) == 0 {
+		return stmts, nil
+	}
+	return append(stmts, object{"Main": pipeline(root.Transforms)}), nil
+}
+
+// pipeline returns a single transform as is, and wraps more transforms in a
+// Pipeline.
+func pipeline(nodes []ast.Node) object {
+	var exprs = make([]interface{}, len(nodes))
+	for i, node := range nodes {
+		exprs[i] = transform(node)
+	}
+	if len(exprs) == 1 {
+		return exprs[0].(object)
+	}
+	return object{"Pipeline": object{"exprs": exprs}}
+}
+
+// queryDef converts the header to a QueryDef, whose arguments other than
+// version are kept as strings.
+func queryDef(header ast.QueryHeader) object {
+	var other = object{}
+	for _, arg := range header.Args {
+		var value string
+		switch v := arg.Value.(type) {
+		case ast.Column:
+			value = v.Name.Name
+		case ast.String:
+			var _, unquoted, err = v.Unquote()
+			if err != nil {
+				errorf("pl: %v", err)
+			}
+			value = unquoted
+		default:
+			errorf("pl: unsupported value %T for header argument %s", v, arg.Name.Name)
+		}
+		other[arg.Name.Name] = value
+	}
+	return object{"QueryDef": object{"version": nil, "other": other}}
+}
+
+func errorf(format string, args ...interface{}) {
+	panic(exportError{fmt.Errorf(format, args...)})
+}
+
+func ident(name string) object {
+	return object{"Ident": strings.Split(strings.Trim(name, "`"), ".")}
+}
+
+func funcCall(name string, args ...interface{}) object {
+	if args == nil {
+		args = []interface{}{}
+	}
+	return object{"FuncCall": object{"name": ident(name), "args": args}}
+}
+
+func literal(variant string, value interface{}) object {
+	return object{"Literal": object{variant: value}}
+}
+
+func transform(node ast.Node) object {
+	switch node := node.(type) {
+	case ast.FromTransform:
+		var table = ident(node.Table.Name)
+		if node.Alias != nil {
+			table["alias"] = node.Alias.Name
+		}
+		return funcCall("from", table)
+	case ast.SelectTransform:
+		return funcCall("select", list(node.List))
+	case ast.DeriveTransform:
+		return funcCall("derive", list(node.List))
+	case ast.SortTransform:
+		return funcCall("sort", list(node.List))
+	case ast.FilterTransform:
+		return funcCall("filter", expr(node.Expr))
+	case ast.TakeTransform:
+		return funcCall("take", expr(node.Expr))
+	case ast.WindowTransform:
+		var call = funcCall("window", pipeline(node.Pipeline))
+		if len(node.Named) > 0 {
+			call["FuncCall"].(object)["named_args"] = namedArgs(node.Named)
+		}
+		return call
+	case ast.GroupTransform:
+		return funcCall("group", list(node.By), pipeline(node.Pipeline))
+	case ast.CustomTransform:
+		return funcCall(node.Name.Name, list(node.List))
+	default:
+		errorf("pl: unsupported transform %T", node)
+		return nil
+	}
+}
+
+// list returns a single item as is, like the reference parser does for
+// transform arguments without brackets.
+func list(list ast.ExprList) object {
+	if len(list.Items) == 1 {
+		return expr(list.Items[0])
+	}
+	return tuple(list)
+}
+
+// tuple returns a list in braces as a Tuple, like newer versions of the
+// reference parser do, and a list in brackets as a List.
+func tuple(list ast.ExprList) object {
+	var items = make([]interface{}, len(list.Items))
+	for i, item := range list.Items {
+		items[i] = expr(item)
+	}
+	if list.Braces {
+		return object{"Tuple": items}
+	}
+	return object{"List": items}
+}
+
+var binOps = map[token.Token]string{
+	token.ADD:    "Add",
+	token.SUB:    "Sub",
+	token.MUL:    "Mul",
+	token.QUO:    "Div",
+	token.INTDIV: "DivInt",
+	token.POW:    "Pow",
+	token.EQL:    "Eq",
+	token.NEQ:    "Ne",
+	token.LSS:    "Lt",
+	token.GTR:    "Gt",
+	token.LEQ:    "Lte",
+	token.GEQ:    "Gte",
+	token.AND:    "And",
+	token.OR:     "Or",
+	token.REGEX:  "RegexSearch",
+}
+
+func expr(e ast.Expr) object {
+	switch e := e.(type) {
+	case ast.Column:
+		return ident(e.Name.Name)
+	case ast.Integer:
+		return literal("Integer", e.Value)
+	case ast.Float:
+		return literal("Float", e.Value)
+	case ast.Boolean:
+		return literal("Boolean", e.Value)
+	case ast.String:
+		return stringLiteral(e)
+	case ast.Date:
+		return literal("Date", fmt.Sprintf("%04d-%02d-%02d", e.Year, e.Month, e.Day))
+	case ast.Time:
+		return literal("Time", fmt.Sprintf("%02d:%02d:%02d", e.Hour, e.Minute, e.Second))
+	case ast.Timestamp:
+		return literal("Timestamp", fmt.Sprintf("%04d-%02d-%02dT%02d:%02d:%02d", e.Year, e.Month, e.Day, e.Hour, e.Minute, e.Second))
+	case ast.Interval:
+		return literal("ValueAndUnit", object{"n": e.Count, "unit": e.Unit})
+	case ast.ParenExpr:
+		return expr(e.X)
+	case ast.AssignExpr:
+		var value = expr(e.Expr)
+		value["alias"] = e.Name
+		return value
+	case ast.UnaryExpr:
+		switch e.Op {
+		case token.ADD:
+			return expr(e.X)
+		case token.SUB:
+			return object{"Unary": object{"op": "Neg", "expr": expr(e.X)}}
+		}
+		errorf("pl: unsupported unary operator %s", e.Op)
+		return nil
+	case ast.BinaryExpr:
+		var op, ok = binOps[e.Op]
+		if !ok {
+			errorf("pl: unsupported binary operator %s", e.Op)
+		}
+		return object{"Binary": object{"left": expr(e.X), "op": op, "right": expr(e.Y)}}
+	case ast.CallExpr:
+		var args = make([]interface{}, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = expr(arg)
+		}
+		var call = funcCall(e.Name.Name, args...)
+		if len(e.Named) > 0 {
+			call["FuncCall"].(object)["named_args"] = namedArgs(e.Named)
+		}
+		return call
+	case ast.CaseExpr:
+		var arms = make([]interface{}, len(e.Arms))
+		for i, arm := range e.Arms {
+			arms[i] = object{"condition": expr(arm.Cond), "value": expr(arm.Value)}
+		}
+		return object{"Case": arms}
+	case ast.ExprList:
+		return tuple(e)
+	case ast.Array:
+		var items = make([]interface{}, len(e.Items))
+		for i, item := range e.Items {
+			items[i] = expr(item)
+		}
+		return object{"Array": items}
+	case ast.FuncLit:
+		var params = make([]interface{}, len(e.Params))
+		for i, param := range e.Params {
+			params[i] = object{"name": param.Name}
+		}
+		return object{"Func": object{"params": params, "body": expr(e.Body)}}
+	case ast.PipeExpr:
+		var exprs = []interface{}{expr(e.X)}
+		for _, call := range e.Calls {
+			exprs = append(exprs, expr(call))
+		}
+		return object{"Pipeline": object{"exprs": exprs}}
+	case ast.RangeExpr:
+		var r = object{"start": nil, "end": nil}
+		if e.Start != nil {
+			r["start"] = expr(e.Start)
+		}
+		if e.End != nil {
+			r["end"] = expr(e.End)
+		}
+		return object{"Range": r}
+	default:
+		errorf("pl: unsupported expression %T", e)
+		return nil
+	}
+}
+
+func namedArgs(args []ast.NamedArg) object {
+	var named = object{}
+	for _, arg := range args {
+		named[arg.Name.Name] = expr(arg.Value)
+	}
+	return named
+}
+
+// stringLiteral converts a string literal to a String literal, or to an
+// SString or FString for s"..." and f"..." strings.
+func stringLiteral(lit ast.String) object {
+	var prefix, value, err = lit.Unquote()
+	if err != nil {
+		errorf("pl: %v", err)
+	}
+
+	switch prefix {
+	case 's':
+		return object{"SString": interpolateItems(value)}
+	case 'f':
+		return object{"FString": interpolateItems(value)}
+	default:
+		return literal("String", value)
+	}
+}
+
+func interpolateItems(value string) []interface{} {
+	var parts, err = ast.Interpolate(value)
+	if err != nil {
+		errorf("pl: %v", err)
+	}
+	var items = []interface{}{}
+	for _, part := range parts {
+		if part.Expr != "" {
+			items = append(items, object{"Expr": ident(part.Expr)})
+		} else {
+			items = append(items, object{"String": part.Text})
+		}
+	}
+	return items
+}
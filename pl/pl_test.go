@@ -0,0 +1,55 @@
+package pl_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/pl"
+)
+
+// TestFixtures compares the output of Marshal for testdata/*.prql with the
+// PL JSON in the corresponding testdata/*.json file.
+func TestFixtures(tt *testing.T) {
+	var paths, err = filepath.Glob("testdata/*.prql")
+	if err != nil {
+		tt.Fatal(err)
+	}
+
+	for _, path := range paths {
+		var src, err = os.ReadFile(path)
+		if err != nil {
+			tt.Fatal(err)
+		}
+		var fixture []byte
+		fixture, err = os.ReadFile(strings.TrimSuffix(path, ".prql") + ".json")
+		if err != nil {
+			tt.Fatal(err)
+		}
+
+		var root, parseErr = parser.NewParser().Parse(strings.NewReader(string(src)))
+		if parseErr != nil {
+			tt.Fatalf("%s: %v", path, parseErr)
+		}
+		var got, marshalErr = pl.Marshal(root)
+		if marshalErr != nil {
+			tt.Fatalf("%s: %v", path, marshalErr)
+		}
+
+		if diff := cmp.Diff(decode(tt, fixture), decode(tt, got)); diff != "" {
+			tt.Fatalf("mismatching results for %s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", path, diff)
+		}
+	}
+}
+
+func decode(tt *testing.T, data []byte) interface{} {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		tt.Fatal(err)
+	}
+	return v
+}
@@ -0,0 +1,441 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/token"
+)
+
+const DefaultWidth = 80
+
+// Config controls the output of Fprint.
+type Config struct {
+	// Width is the line width above which bracket lists are wrapped, one
+	// item per line.
+	Width int
+	// Braces prints all lists with braces, migrating the older [a, b]
+	// syntax to {a, b}. Otherwise lists keep the syntax they were parsed
+	// with.
+	Braces bool
+}
+
+type printError struct {
+	err error
+}
+
+type printer struct {
+	cfg      *Config
+	b        strings.Builder
+	comments ast.CommentMap
+	item     int // index of the first item of the next list of the transform
+	nested   int // number of the last nested transform or pipeline end, see ast.NodePath
+}
+
+// Fprint writes the canonical PRQL form of node to w using DefaultWidth.
+func Fprint(w io.Writer, node ast.Node) error {
+	var cfg = Config{Width: DefaultWidth}
+	return cfg.Fprint(w, node)
+}
+
+// Fprint writes the canonical PRQL form of node to w. The node can be an
+// *ast.Root, a transform or an expression.
+func (cfg *Config) Fprint(w io.Writer, node ast.Node) (retErr error) {
+	var p = &printer{cfg: cfg}
+
+	defer func() {
+		var r = recover()
+		if err, ok := r.(printError); ok {
+			retErr = err.err
+		} else if r != nil {
+			panic(r)
+		}
+	}()
+
+	switch node := node.(type) {
+	case *ast.Root:
+		p.root(*node)
+	case ast.Root:
+		p.root(node)
+	case ast.Expr:
+		p.b.WriteString(p.expr(node))
+	default:
+		p.b.WriteString(p.transform(-1, node))
+	}
+
+	var _, err = io.WriteString(w, p.b.String())
+	return err
+}
+
+func (p *printer) errorf(format string, args ...interface{}) {
+	panic(printError{fmt.Errorf(format, args...)})
+}
+
+func (p *printer) root(root ast.Root) {
+	p.comments = root.Comments
+	if root.Header != nil {
+		var nc = p.comments[ast.NodePath{Transform: -1, Item: -1}]
+		p.b.WriteString(leading(nc.Leading, ""))
+		p.b.WriteString(p.header(*root.Header))
+		p.b.WriteString(trailing(nc.Trailing))
+		p.b.WriteByte('\n')
+	}
+	for i, node := range root.Transforms {
+		p.item, p.nested = 0, 0
+		var nc = p.comments[ast.NodePath{Transform: i, Item: -1}]
+		p.b.WriteString(leading(nc.Leading, ""))
+		p.b.WriteString(p.transform(i, node))
+		p.b.WriteString(trailing(nc.Trailing))
+		p.b.WriteByte('\n')
+	}
+	var nc = p.comments[ast.NodePath{Transform: len(root.Transforms), Item: -1}]
+	p.b.WriteString(leading(nc.Leading, ""))
+}
+
+func (p *printer) header(header ast.QueryHeader) string {
+	var b strings.Builder
+	b.WriteString("prql")
+	for _, arg := range header.Args {
+		b.WriteString(" " + arg.Name.Name + ":" + p.expr(arg.Value))
+	}
+	return b.String()
+}
+
+// leading returns the lines of a leading comment group, each prefixed with
+// indent.
+func leading(group *ast.CommentGroup, indent string) string {
+	if group == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, c := range group.List {
+		b.WriteString(indent + strings.TrimRight(c.Text, " \t") + "\n")
+	}
+	return b.String()
+}
+
+func trailing(group *ast.CommentGroup) string {
+	if group == nil {
+		return ""
+	}
+	var texts = make([]string, len(group.List))
+	for i, c := range group.List {
+		texts[i] = strings.TrimRight(c.Text, " \t")
+	}
+	return " " + strings.Join(texts, " ")
+}
+
+// transform prints the transform at index idx of the root, or a standalone
+// transform if idx is -1.
+func (p *printer) transform(idx int, node ast.Node) string {
+	switch node := node.(type) {
+	case ast.FromTransform:
+		if node.Alias != nil {
+			return fmt.Sprintf("from %s = %s", node.Alias.Name, node.Table.Name)
+		}
+		return fmt.Sprintf("from %s", node.Table.Name)
+	case ast.SelectTransform:
+		return p.list(idx, "select", node.List)
+	case ast.DeriveTransform:
+		return p.list(idx, "derive", node.List)
+	case ast.SortTransform:
+		return p.list(idx, "sort", node.List)
+	case ast.FilterTransform:
+		return "filter " + p.expr(node.Expr)
+	case ast.TakeTransform:
+		return "take " + p.expr(node.Expr)
+	case ast.WindowTransform:
+		var b strings.Builder
+		b.WriteString("window")
+		for _, arg := range node.Named {
+			b.WriteString(" " + arg.Name.Name + ":" + p.arg(arg.Value))
+		}
+		return p.pipeline(idx, b.String(), node.Pipeline)
+	case ast.GroupTransform:
+		var by = p.list(idx, "group", node.By)
+		if len(node.By.Items) == 1 {
+			// a call would take the pipeline as an argument
+			if _, ok := node.By.Items[0].(ast.Column); !ok {
+				var left, right = p.delims(node.By)
+				by = "group " + left + p.expr(node.By.Items[0]) + right
+			}
+		}
+		return p.pipeline(idx, by, node.Pipeline)
+	case ast.CustomTransform:
+		return p.list(idx, node.Name.Name, node.List)
+	case ast.QueryHeader:
+		return p.header(node)
+	default:
+		p.errorf("printer: unsupported transform %T", node)
+		return ""
+	}
+}
+
+// list prints a transform keyword followed by its expression list. Lists
+// with a single item are printed without brackets, and lists that do not fit
+// in the configured width or have comments are wrapped with one item per
+// line.
+func (p *printer) list(idx int, keyword string, list ast.ExprList) string {
+	var items = make([]string, len(list.Items))
+	var comments = make([]ast.NodeComments, len(list.Items))
+	var hasComments = false
+	for i, item := range list.Items {
+		items[i] = p.expr(item)
+		comments[i] = p.comments[ast.NodePath{Transform: idx, Item: p.item + i}]
+		hasComments = hasComments || comments[i] != (ast.NodeComments{})
+	}
+	p.item += len(list.Items)
+
+	if !hasComments {
+		if len(items) == 1 {
+			return keyword + " " + items[0]
+		}
+
+		var left, right = p.delims(list)
+		var line = keyword + " " + left + strings.Join(items, ", ") + right
+		if len(line) <= p.cfg.Width || len(items) == 0 {
+			return line
+		}
+	}
+
+	var left, right = p.delims(list)
+	var b strings.Builder
+	b.WriteString(keyword + " " + left + "\n")
+	for i, item := range items {
+		b.WriteString(leading(comments[i].Leading, "  "))
+		b.WriteString("  " + item + "," + trailing(comments[i].Trailing) + "\n")
+	}
+	b.WriteString(right)
+	return b.String()
+}
+
+// delims returns the brackets or braces that list is printed with.
+func (p *printer) delims(list ast.ExprList) (string, string) {
+	if list.Braces || p.cfg.Braces {
+		return "{", "}"
+	}
+	return "[", "]"
+}
+
+// pipeline prints a transform followed by its pipeline, which is nested in
+// the transform at index idx of the root. Pipelines that do not fit in the
+// configured width, or whose transforms span lines or have comments, are
+// printed with one transform per line.
+func (p *printer) pipeline(idx int, prefix string, pipeline []ast.Node) string {
+	var transforms = make([]string, len(pipeline))
+	var comments = make([]ast.NodeComments, len(pipeline))
+	var hasComments = false
+	for i, node := range pipeline {
+		p.nested++
+		comments[i] = p.comments[ast.NodePath{Transform: idx, Item: -1, Nested: p.nested}]
+		transforms[i] = p.transform(idx, node)
+		hasComments = hasComments || comments[i] != (ast.NodeComments{})
+	}
+	p.nested++
+	var end = p.comments[ast.NodePath{Transform: idx, Item: -1, Nested: p.nested}]
+	hasComments = hasComments || end.Leading != nil
+
+	var line = prefix + " (" + strings.Join(transforms, " | ") + ")"
+	if len(line) <= p.cfg.Width && !strings.Contains(line, "\n") && !hasComments {
+		return line
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix + " (\n")
+	for i, t := range transforms {
+		b.WriteString(indent(leading(comments[i].Leading, "")+t+trailing(comments[i].Trailing), "  ") + "\n")
+	}
+	b.WriteString(leading(end.Leading, "  "))
+	b.WriteString(")")
+	return b.String()
+}
+
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+func (p *printer) expr(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case ast.Column:
+		return expr.Name.Name
+	case ast.String:
+		return expr.Value
+	case ast.Integer:
+		return strconv.FormatInt(expr.Value, 10)
+	case ast.Float:
+		var s = strconv.FormatFloat(expr.Value, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	case ast.Boolean:
+		return strconv.FormatBool(expr.Value)
+	case ast.Date:
+		return fmt.Sprintf("@%04d-%02d-%02d", expr.Year, expr.Month, expr.Day)
+	case ast.Time:
+		return fmt.Sprintf("@%02d:%02d:%02d", expr.Hour, expr.Minute, expr.Second)
+	case ast.Timestamp:
+		return fmt.Sprintf("@%04d-%02d-%02dT%02d:%02d:%02d", expr.Year, expr.Month, expr.Day, expr.Hour, expr.Minute, expr.Second)
+	case ast.Interval:
+		return fmt.Sprintf("%d%s", expr.Count, expr.Unit)
+	case ast.ParenExpr:
+		// Parentheses are reinserted by BinaryExpr and UnaryExpr only where
+		// they are required.
+		return p.expr(expr.X)
+	case ast.AssignExpr:
+		return expr.Name + " = " + p.expr(expr.Expr)
+	case ast.UnaryExpr:
+		var x = p.expr(expr.X)
+		switch unparen(expr.X).(type) {
+		case ast.Integer, ast.Float, ast.Column:
+		default:
+			x = "(" + x + ")"
+		}
+		return opString(expr.Op) + x
+	case ast.BinaryExpr:
+		var prec = token.Precedences[expr.Op]
+		var x = p.expr(expr.X)
+		var y = p.expr(expr.Y)
+		// Of two operands with the precedence of the operator, the one that
+		// the operator does not group to needs parentheses.
+		var right = expr.Op.RightAssociative()
+		if xPrec, ok := binaryPrecedence(expr.X); ok && (xPrec < prec || xPrec == prec && right) {
+			x = "(" + x + ")"
+		}
+		if yPrec, ok := binaryPrecedence(expr.Y); ok && (yPrec < prec || yPrec == prec && !right) {
+			y = "(" + y + ")"
+		}
+		return x + " " + opString(expr.Op) + " " + y
+	case ast.CallExpr:
+		var parts = []string{expr.Name.Name}
+		for _, arg := range expr.Named {
+			parts = append(parts, arg.Name.Name+":"+p.arg(arg.Value))
+		}
+		for _, arg := range expr.Args {
+			parts = append(parts, p.arg(arg))
+		}
+		return strings.Join(parts, " ")
+	case ast.CaseExpr:
+		var arms = make([]string, len(expr.Arms))
+		for i, arm := range expr.Arms {
+			arms[i] = p.expr(arm.Cond) + " => " + p.expr(arm.Value)
+		}
+		return "case [" + strings.Join(arms, ", ") + "]"
+	case ast.RangeExpr:
+		var start, end string
+		if expr.Start != nil {
+			start = p.bound(expr.Start)
+		}
+		if expr.End != nil {
+			end = p.bound(expr.End)
+		}
+		return start + ".." + end
+	case ast.ExprList:
+		var items = make([]string, len(expr.Items))
+		for i, item := range expr.Items {
+			items[i] = p.expr(item)
+		}
+		var left, right = p.delims(expr)
+		return left + strings.Join(items, ", ") + right
+	case ast.Array:
+		var items = make([]string, len(expr.Items))
+		for i, item := range expr.Items {
+			items[i] = p.expr(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case ast.FuncLit:
+		var params = make([]string, len(expr.Params))
+		for i, param := range expr.Params {
+			params[i] = param.Name
+		}
+		var fn = strings.Join(params, " ") + " -> " + p.expr(expr.Body)
+		if len(params) > 1 {
+			return "(" + fn + ")"
+		}
+		return fn
+	case ast.PipeExpr:
+		var stages = []string{p.expr(expr.X)}
+		for _, call := range expr.Calls {
+			stages = append(stages, p.expr(call))
+		}
+		return "(" + strings.Join(stages, " | ") + ")"
+	default:
+		p.errorf("printer: unsupported expression %T", expr)
+		return ""
+	}
+}
+
+// arg prints an argument of a call, in parentheses unless it is a literal or
+// a column.
+func (p *printer) arg(expr ast.Expr) string {
+	switch e := unparen(expr).(type) {
+	case ast.BinaryExpr, ast.UnaryExpr, ast.CallExpr, ast.AssignExpr:
+		return "(" + p.expr(expr) + ")"
+	case ast.FuncLit:
+		if len(e.Params) == 1 {
+			return "(" + p.expr(expr) + ")"
+		}
+	}
+	return p.expr(expr)
+}
+
+// bound prints the start or end of a range, in parentheses unless it is a
+// literal, a column or a signed literal.
+func (p *printer) bound(expr ast.Expr) string {
+	if unary, ok := unparen(expr).(ast.UnaryExpr); ok {
+		switch unparen(unary.X).(type) {
+		case ast.Integer, ast.Float, ast.Column:
+			return p.expr(expr)
+		}
+	}
+	return p.arg(expr)
+}
+
+func unparen(expr ast.Expr) ast.Expr {
+	for {
+		if paren, ok := expr.(ast.ParenExpr); ok {
+			expr = paren.X
+		} else {
+			return expr
+		}
+	}
+}
+
+func binaryPrecedence(expr ast.Expr) (token.Precedence, bool) {
+	if binary, ok := unparen(expr).(ast.BinaryExpr); ok {
+		return token.Precedences[binary.Op], true
+	}
+	// the body of a function extends as far as possible
+	if fn, ok := unparen(expr).(ast.FuncLit); ok && len(fn.Params) == 1 {
+		return token.LowestPrecedence, true
+	}
+	return 0, false
+}
+
+var opStrings = map[token.Token]string{
+	token.ADD:    "+",
+	token.SUB:    "-",
+	token.MUL:    "*",
+	token.QUO:    "/",
+	token.EQL:    "==",
+	token.NEQ:    "!=",
+	token.LSS:    "<",
+	token.GTR:    ">",
+	token.LEQ:    "<=",
+	token.GEQ:    ">=",
+	token.AND:    "and",
+	token.OR:     "or",
+	token.REGEX:  "~=",
+	token.INTDIV: "//",
+	token.POW:    "**",
+}
+
+func opString(op token.Token) string {
+	if s, ok := opStrings[op]; ok {
+		return s
+	}
+	panic(printError{fmt.Errorf("printer: unsupported operator %s", op)})
+}
@@ -0,0 +1,182 @@
+package printer_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/printer"
+)
+
+func TestPrinter(tt *testing.T) {
+	var testCases = []struct {
+		src    string
+		want   string
+		width  int
+		braces bool
+	}{
+		{
+			src:  `from table1`,
+			want: "from table1\n",
+		},
+		{
+			src:  `from   e1=table1`,
+			want: "from e1 = table1\n",
+		},
+		{
+			src:  "from table1\n\n\nselect [column1]\nderive [x=1+2]",
+			want: "from table1\nselect column1\nderive x = 1 + 2\n",
+		},
+		{
+			src: `select [
+			  column1,
+			  123, 1.23, 1.0,
+			  "hello world",
+			  @2022-12-31, @01:02:03, @2022-12-31T01:02:03,
+			  123seconds,
+			]`,
+			want:  `select [column1, 123, 1.23, 1.0, "hello world", @2022-12-31, @01:02:03, @2022-12-31T01:02:03, 123seconds]` + "\n",
+			width: 200,
+		},
+		{
+			src:   `select [(1), (1 + 2), y + (1), z = ((z*2) + 1), (1 + 2) * 3, 1 * 2 + 3 + 4 * 5, -(1 + x), +3 + -2.1]`,
+			want:  `select [1, 1 + 2, y + 1, z = z * 2 + 1, (1 + 2) * 3, 1 * 2 + 3 + 4 * 5, -(1 + x), +3 + -2.1]` + "\n",
+			width: 200,
+		},
+		{
+			src:  `derive [x = (1 - 2) - 3, y = 1 - (2 - 3), z = 1 / (2 * 3)]`,
+			want: "derive [x = 1 - 2 - 3, y = 1 - (2 - 3), z = 1 / (2 * 3)]\n",
+		},
+		{
+			src: `
+# comment1
+from table1   # comment2
+
+# comment3
+select [
+  column1,    # comment4
+  # comment5
+  column2
+]   # comment6
+# comment7   `,
+			want: "# comment1\nfrom table1 # comment2\n# comment3\nselect [\n  column1, # comment4\n  # comment5\n  column2,\n] # comment6\n# comment7\n",
+		},
+		{
+			src:  "from t\ngroup a (\n# inside pipeline\nderive x = 1   # after x\nwindow (sort [\ny, # after y\n])\n  # end of pipeline\n) # after group\nselect b",
+			want: "from t\ngroup a (\n  # inside pipeline\n  derive x = 1 # after x\n  window (\n    sort [\n      y, # after y\n    ]\n  )\n  # end of pipeline\n) # after group\nselect b\n",
+		},
+		{
+			src:  "filter a > 1 # after filter\nderive x = case [\n  # arm one\n  a > 1 => 1,\n  true => 2, # arm two\n]\nfilter case [\n  # arm three\n  b => true\n]",
+			want: "filter a > 1 # after filter\nderive [\n  # arm one\n  x = case [a > 1 => 1, true => 2], # arm two\n]\n# arm three\nfilter case [b => true]\n",
+		},
+		{
+			src:  "# comment1\nprql   dialect:duckdb # comment2\nfrom table1\nderive [a=true, b=false]\ntake   10",
+			want: "# comment1\nprql dialect:duckdb # comment2\nfrom table1\nderive [a = true, b = false]\ntake 10\n",
+		},
+		{
+			src:  "from t\ntake  5 .. 10\ntake ..3\ntake (2)..",
+			want: "from t\ntake 5..10\ntake ..3\ntake 2..\n",
+		},
+		{
+			src:  "filter ((a>1) or b) and (c != 2 or d <= 3)\nfilter x>=1+2\nsort [-x,+y]",
+			want: "filter (a > 1 or b) and (c != 2 or d <= 3)\nfilter x >= 1 + 2\nsort [-x, +y]\n",
+		},
+		{
+			src:   `derive [r = round   2 (price * 1.1), s = sum amount + 1, t = trim chars:" " (name), l = lag 1 (-x)]`,
+			want:  `derive [r = round 2 (price * 1.1), s = sum amount + 1, t = trim chars:" " name, l = lag 1 (-x)]` + "\n",
+			width: 200,
+		},
+		{
+			src:  "group [dept]  (sort date|window rows:-3..0 (derive r = average value))\nwindow expanding:true (\nderive t = sum value\n)",
+			want: "group dept (sort date | window rows:-3..0 (derive r = average value))\nwindow expanding:true (derive t = sum value)\n",
+		},
+		{
+			src:  "derive g = case [\n  score>90 => \"A\",\n  (score > 80) => \"B\"\n  true => \"C\"\n]",
+			want: "derive g = case [score > 90 => \"A\", score > 80 => \"B\", true => \"C\"]\n",
+		},
+		{
+			src:   "window range:..(1+2) (derive [a = sum value, b = sum value])",
+			want:  "window range:..(1 + 2) (\n  derive [\n    a = sum value,\n    b = sum value,\n  ]\n)\n",
+			width: 20,
+		},
+		{
+			src:  "select {a=x,b}\nderive t = {1, [c, d]}",
+			want: "select {a = x, b}\nderive t = {1, [c, d]}\n",
+		},
+		{
+			src:  "filter (in  [\"US\",\"CA\"] country) and (in 1..  y)\nderive z = in ..(-1) (x + 1)",
+			want: "filter in [\"US\", \"CA\"] country and in 1.. y\nderive z = in ..-1 (x + 1)\n",
+		},
+		{
+			src:  "derive x = ((amount+1)|round 2 |abs)\nfilter (country | in [\"US\", \"CA\"]) and (lower (name|upper)) == \"x\"",
+			want: "derive x = (amount + 1 | round 2 | abs)\nfilter (country | in [\"US\", \"CA\"]) and lower (name | upper) == \"x\"\n",
+		},
+		{
+			src:  "filter name~=\"^A\" and (email | text.ends_with  \".com\")",
+			want: "filter name ~= \"^A\" and (email | text.ends_with \".com\")\n",
+		},
+		{
+			src:  "derive [a = (x - y) - z, b = x - (y - z), c = (x // y) // z, d = x // (y // z)]",
+			want: "derive [a = x - y - z, b = x - (y - z), c = x // y // z, d = x // (y // z)]\n",
+		},
+		{
+			src:  "derive [a = (x**2)**3, b = x**(2**3), c = (x//2)*y, d = (-x)**2]",
+			want: "derive [a = (x ** 2) ** 3, b = x ** 2 ** 3, c = x // 2 * y, d = -x ** 2]\n",
+		},
+		{
+			src:  "derive [f = x->(x+1), g = (a  b -> a * b), h = (x -> x) + round 2 (y -> -y)]",
+			want: "derive [f = x -> x + 1, g = (a b -> a * b), h = (x -> x) + round 2 (y -> -y)]\n",
+		},
+		{
+			src:    "select [a, b]\nsort [x]\ngroup [a+1] (take 1)\nderive t = [1, {c, d}]",
+			want:   "select {a, b}\nsort x\ngroup {a + 1} (take 1)\nderive t = [1, {c, d}]\n",
+			braces: true,
+		},
+		{
+			src:    `select {column1, column2, column3}`,
+			want:   "select {\n  column1,\n  column2,\n  column3,\n}\n",
+			width:  20,
+			braces: true,
+		},
+		{
+			src:   `select [column1, column2, column3]`,
+			want:  "select [\n  column1,\n  column2,\n  column3,\n]\n",
+			width: 20,
+		},
+	}
+
+	for _, tc := range testCases {
+		var width = tc.width
+		if width == 0 {
+			width = printer.DefaultWidth
+		}
+		var cfg = printer.Config{Width: width, Braces: tc.braces}
+
+		var got = format(tt, &cfg, tc.src)
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			tt.Fatalf("mismatching results\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.src, diff)
+		}
+
+		// formatting is idempotent
+		var again = format(tt, &cfg, got)
+		if diff := cmp.Diff(got, again); diff != "" {
+			tt.Fatalf("formatting is not idempotent\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", got, diff)
+		}
+	}
+}
+
+func format(tt *testing.T, cfg *printer.Config, src string) string {
+	var p = parser.NewParser()
+	p.SetParseComments(true)
+	var root, err = p.Parse(strings.NewReader(src))
+	if err != nil {
+		tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", src, err)
+	}
+
+	var b strings.Builder
+	if err := cfg.Fprint(&b, root); err != nil {
+		tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", src, err)
+	}
+	return b.String()
+}
@@ -0,0 +1,279 @@
+// Package resolver resolves the column references of PRQL queries.
+package resolver
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/scanner"
+)
+
+// Schema maps table names to the names of their columns. Tables that are not
+// in the schema have unknown columns, and any name used while such a table is
+// in scope resolves to one of its columns.
+type Schema map[string][]string
+
+// Binding is what a column reference resolves to: a column of the table read
+// by from, or a column declared by an assignment in derive or select.
+type Binding struct {
+	Name  string
+	Table string       // the table of a table column, "" for declared columns
+	Decl  ast.NodePath // the list item that declares a declared column
+}
+
+// Scope is the set of columns available after a transform.
+type Scope struct {
+	// Table and Alias are those of the from transform, as long as the
+	// columns of the table that are not listed in Columns are in scope.
+	Table   string
+	Alias   string
+	Columns []Binding
+}
+
+// Info holds the results of Resolve.
+type Info struct {
+	// Columns maps the positions of the identifiers of ast.Column
+	// expressions to what they resolve to.
+	Columns map[scanner.Pos]Binding
+	// Scopes holds the scope after each transform of Root.Transforms.
+	Scopes []Scope
+	// Params maps the positions of the identifiers of ast.Column
+	// expressions that refer to a parameter of an enclosing ast.FuncLit to
+	// that parameter.
+	Params map[scanner.Pos]ast.Ident
+}
+
+// Lookup returns what c resolves to.
+func (info *Info) Lookup(c ast.Column) (Binding, bool) {
+	var b, ok = info.Columns[c.Name.Pos]
+	return b, ok
+}
+
+// Error is a reference to an unknown table or column.
+type Error struct {
+	Pos  scanner.Pos
+	Kind string // "table" or "column"
+	Name string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("unknown %s %s at %d", e.Kind, e.Name, e.Pos)
+}
+
+type resolver struct {
+	schema Schema
+	info   *Info
+	errs   []Error
+
+	scope Scope
+	// open reports whether the table in scope is not in the schema
+	open bool
+	path ast.NodePath
+	// next is the Item of the next list item of the transform, as the
+	// items of nested pipelines are numbered after the enclosing ones
+	next int
+	// params are the parameters of the enclosing functions, innermost last
+	params []ast.Ident
+}
+
+// Resolve resolves the column references of root. Columns interpolated in
+// f-strings and s-strings are not resolved. If schema is nil, every table is
+// assumed to have the columns the query uses.
+func Resolve(root *ast.Root, schema Schema) (*Info, []Error) {
+	var r = &resolver{
+		schema: schema,
+		info:   &Info{Columns: map[scanner.Pos]Binding{}, Params: map[scanner.Pos]ast.Ident{}},
+	}
+	for i, node := range root.Transforms {
+		r.path = ast.NodePath{Transform: i, Item: -1}
+		r.next = 0
+		r.transform(node)
+		r.info.Scopes = append(r.info.Scopes, r.snapshot())
+	}
+	return r.info, r.errs
+}
+
+func (r *resolver) snapshot() Scope {
+	var s = r.scope
+	s.Columns = append([]Binding(nil), r.scope.Columns...)
+	return s
+}
+
+func (r *resolver) transform(node ast.Node) {
+	switch node := node.(type) {
+	case ast.FromTransform:
+		r.from(node)
+	case ast.DeriveTransform:
+		for _, item := range node.List.Items {
+			r.item()
+			if assign, ok := item.(ast.AssignExpr); ok {
+				r.expr(assign.Expr)
+				r.declare(Binding{Name: assign.Name, Decl: r.path})
+			} else {
+				r.expr(item)
+			}
+		}
+	case ast.SelectTransform:
+		var columns []Binding
+		for _, item := range node.List.Items {
+			r.item()
+			switch item := item.(type) {
+			case ast.AssignExpr:
+				r.expr(item.Expr)
+				columns = append(columns, Binding{Name: item.Name, Decl: r.path})
+			case ast.Column:
+				if b, ok := r.column(item); ok {
+					columns = append(columns, b)
+				}
+			default:
+				r.expr(item)
+			}
+		}
+		r.scope = Scope{Columns: columns}
+		r.open = false
+	case ast.SortTransform:
+		for _, item := range node.List.Items {
+			r.item()
+			r.expr(item)
+		}
+	case ast.CustomTransform:
+		for _, item := range node.List.Items {
+			r.item()
+			r.expr(item)
+		}
+	case ast.FilterTransform:
+		r.expr(node.Expr)
+	case ast.TakeTransform:
+		r.expr(node.Expr)
+	case ast.WindowTransform:
+		for _, arg := range node.Named {
+			r.expr(arg.Value)
+		}
+		for _, t := range node.Pipeline {
+			r.transform(t)
+		}
+	case ast.GroupTransform:
+		for _, item := range node.By.Items {
+			r.item()
+			r.expr(item)
+		}
+		for _, t := range node.Pipeline {
+			r.transform(t)
+		}
+	}
+}
+
+// item moves r.path to the next list item of the transform.
+func (r *resolver) item() {
+	r.path.Item = r.next
+	r.next++
+}
+
+func (r *resolver) from(node ast.FromTransform) {
+	var table = name(node.Table)
+	r.scope = Scope{Table: table}
+	if node.Alias != nil {
+		r.scope.Alias = name(*node.Alias)
+	}
+
+	var columns, ok = r.schema[table]
+	r.open = !ok
+	if r.schema != nil && !ok {
+		r.errs = append(r.errs, Error{Pos: node.Table.Pos, Kind: "table", Name: table})
+		// the columns of the table are unknown, but they should not be
+		// reported as errors too
+		return
+	}
+	for _, c := range columns {
+		r.scope.Columns = append(r.scope.Columns, Binding{Name: c, Table: table})
+	}
+}
+
+// declare adds b to the scope, replacing the column with the same name.
+func (r *resolver) declare(b Binding) {
+	for i, c := range r.scope.Columns {
+		if c.Name == b.Name {
+			r.scope.Columns[i] = b
+			return
+		}
+	}
+	r.scope.Columns = append(r.scope.Columns, b)
+}
+
+// column records and returns what c resolves to. A name qualified by the
+// table or alias of from, e.g. e.id after from e = employees, is a column of
+// the table even if a declared column has the same name.
+func (r *resolver) column(c ast.Column) (Binding, bool) {
+	var n = name(c.Name)
+	if relation, column, ok := strings.Cut(n, "."); ok && r.scope.Table != "" && (relation == r.scope.Table || relation == r.scope.Alias) {
+		if r.open || contains(r.schema[r.scope.Table], column) {
+			var b = Binding{Name: column, Table: r.scope.Table}
+			r.info.Columns[c.Name.Pos] = b
+			return b, true
+		}
+		r.errs = append(r.errs, Error{Pos: c.Name.Pos, Kind: "column", Name: n})
+		return Binding{}, false
+	}
+	for _, b := range r.scope.Columns {
+		if b.Name == n {
+			r.info.Columns[c.Name.Pos] = b
+			return b, true
+		}
+	}
+	if r.open && r.scope.Table != "" {
+		var b = Binding{Name: n, Table: r.scope.Table}
+		r.scope.Columns = append(r.scope.Columns, b)
+		r.info.Columns[c.Name.Pos] = b
+		return b, true
+	}
+	r.errs = append(r.errs, Error{Pos: c.Name.Pos, Kind: "column", Name: n})
+	return Binding{}, false
+}
+
+// expr resolves the column references of e. The parameters of functions
+// shadow the columns in their bodies.
+func (r *resolver) expr(e ast.Expr) {
+	ast.Inspect(e, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case ast.FuncLit:
+			var n = len(r.params)
+			r.params = append(r.params, node.Params...)
+			r.expr(node.Body)
+			r.params = r.params[:n]
+			return false
+		case ast.Column:
+			if param, ok := r.param(node); ok {
+				r.info.Params[node.Name.Pos] = param
+			} else {
+				r.column(node)
+			}
+		}
+		return true
+	})
+}
+
+// param returns the innermost parameter that c refers to.
+func (r *resolver) param(c ast.Column) (ast.Ident, bool) {
+	var n = name(c.Name)
+	for i := len(r.params) - 1; i >= 0; i-- {
+		if name(r.params[i]) == n {
+			return r.params[i], true
+		}
+	}
+	return ast.Ident{}, false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// name returns the name of an identifier without backquotes.
+func name(ident ast.Ident) string {
+	return strings.Trim(ident.Name, "`")
+}
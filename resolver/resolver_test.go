@@ -0,0 +1,190 @@
+package resolver_test
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/resolver"
+)
+
+var schema = resolver.Schema{
+	"employees": {"id", "name", "salary", "dept"},
+}
+
+func TestResolve(tt *testing.T) {
+	var testCases = []struct {
+		src    string
+		schema resolver.Schema
+		want   []string // "pos: binding", in order of position
+		errs   []string
+	}{
+		{
+			src: `
+from e = employees
+derive [gross = salary + 1, net = gross * 2]
+filter net > 10
+select [name, total = net]
+sort total
+			`,
+			schema: schema,
+			want: []string{
+				"36: employees.salary",
+				"54: gross (1, 0)",
+				"72: net (1, 1)",
+				"89: employees.name",
+				"103: net (1, 1)",
+				"113: total (3, 1)",
+			},
+		},
+		{
+			src:    "from employees\nselect [name]\nfilter salary > 0\nderive [`x y` = dept]",
+			schema: schema,
+			want: []string{
+				"23: employees.name",
+			},
+			errs: []string{
+				"unknown column salary at 36",
+				"unknown column dept at 63",
+			},
+		},
+		{
+			// without a schema, all names are columns of the table
+			src: "from t\nderive [a = b]\nselect [a, `c`]",
+			want: []string{
+				"19: t.b",
+				"30: a (1, 0)",
+				"33: t.c",
+			},
+		},
+		{
+			// the columns of unknown tables are not reported
+			src:    "from departments\nselect [a]",
+			schema: schema,
+			want: []string{
+				"25: departments.a",
+			},
+			errs: []string{
+				"unknown table departments at 5",
+			},
+		},
+		{
+			// the items of nested pipelines are numbered after the keys
+			src:    "from employees\ngroup dept (sort salary | derive [r = rank, s = r + 1])\nselect [s, bonus]",
+			schema: schema,
+			want: []string{
+				"21: employees.dept",
+				"32: employees.salary",
+				"63: r (1, 2)",
+				"79: s (1, 3)",
+			},
+			errs: []string{
+				"unknown column bonus at 82",
+			},
+		},
+		{
+			// the table and its alias qualify the columns of the table
+			src:    "from e = employees\nderive [name = 1]\nselect [e.id, `employees.name`, name, e.bonus, d.id]",
+			schema: schema,
+			want: []string{
+				"45: employees.id",
+				"51: employees.name",
+				"69: name (1, 0)",
+			},
+			errs: []string{
+				"unknown column e.bonus at 75",
+				"unknown column d.id at 84",
+			},
+		},
+		{
+			// without a schema, qualified names are columns of the table
+			src:  "from e = t\nselect [e.a, t.b]",
+			want: []string{"19: t.a", "24: t.b"},
+		},
+		{
+			// parameters shadow columns in the bodies of functions
+			src:    "from employees\nderive [f = (salary dept -> salary + dept + id), g = x -> (y -> x + y + name)]",
+			schema: schema,
+			want: []string{
+				"43: param salary at 28",
+				"52: param dept at 35",
+				"59: employees.id",
+				"79: param x at 68",
+				"83: param y at 74",
+				"87: employees.name",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		var p = parser.NewParser()
+		var root, err = p.Parse(strings.NewReader(tc.src))
+		if err != nil {
+			tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", tc.src, err)
+		}
+
+		var info, errs = resolver.Resolve(root, tc.schema)
+		var got []string
+		for pos, b := range info.Columns {
+			var desc = fmt.Sprintf("%s (%d, %d)", b.Name, b.Decl.Transform, b.Decl.Item)
+			if b.Table != "" {
+				desc = b.Table + "." + b.Name
+			}
+			got = append(got, fmt.Sprintf("%d: %s", pos, desc))
+		}
+		for pos, param := range info.Params {
+			got = append(got, fmt.Sprintf("%d: param %s at %d", pos, param.Name, param.Pos))
+		}
+		sort.Slice(got, func(i, j int) bool {
+			var a, b int
+			fmt.Sscan(got[i], &a)
+			fmt.Sscan(got[j], &b)
+			return a < b
+		})
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			tt.Fatalf("mismatching results\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.src, diff)
+		}
+
+		var gotErrs []string
+		for _, err := range errs {
+			gotErrs = append(gotErrs, err.Error())
+		}
+		if diff := cmp.Diff(tc.errs, gotErrs); diff != "" {
+			tt.Fatalf("mismatching errors\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.src, diff)
+		}
+	}
+}
+
+func TestScopes(tt *testing.T) {
+	var src = "from e = employees\nderive [bonus = salary / 10]\nselect [name, bonus]\ntake 10"
+	var p = parser.NewParser()
+	var root, err = p.Parse(strings.NewReader(src))
+	if err != nil {
+		tt.Fatal(err)
+	}
+	var info, errs = resolver.Resolve(root, schema)
+	if len(errs) != 0 {
+		tt.Fatalf("unexpected errors %v", errs)
+	}
+
+	var got []string
+	for _, scope := range info.Scopes {
+		var names []string
+		for _, b := range scope.Columns {
+			names = append(names, b.Name)
+		}
+		got = append(got, fmt.Sprintf("%s=%s %v", scope.Alias, scope.Table, names))
+	}
+	var want = []string{
+		"e=employees [id name salary dept]",
+		"e=employees [id name salary dept bonus]",
+		"= [name bonus]",
+		"= [name bonus]",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		tt.Fatalf("mismatching results\ndiff guide:\n  - want\n  + got\ndiff:\n%s", diff)
+	}
+}
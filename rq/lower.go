@@ -0,0 +1,325 @@
+package rq
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/desugar"
+	"github.com/siadat/prql-parser/stdlib"
+	"github.com/siadat/prql-parser/token"
+)
+
+type lowerError struct {
+	err error
+}
+
+type lowerer struct {
+	nextID CId
+	// scan is the relation read by from, as long as its columns are in
+	// scope. Columns that are not in names are looked up in it.
+	scan  *Scan
+	names map[string]CId
+	// projects are the projects of aggregates above scan, which pass the
+	// columns of scan that are looked up later through.
+	projects []*Project
+	// sort is the keys of the last sort, which order the rows of windows
+	// outside group.
+	sort []SortKey
+}
+
+// Lower translates the pipeline of root into a relation.
+func Lower(root *ast.Root) (retRel Relation, retErr error) {
+	defer func() {
+		var r = recover()
+		if err, ok := r.(lowerError); ok {
+			retErr = err.err
+		} else if r != nil {
+			panic(r)
+		}
+	}()
+
+	var l = &lowerer{}
+	return l.pipeline(desugar.Root(root).Transforms), nil
+}
+
+func errorf(format string, args ...interface{}) {
+	panic(lowerError{fmt.Errorf("rq: "+format, args...)})
+}
+
+func (l *lowerer) newID() CId {
+	var id = l.nextID
+	l.nextID++
+	return id
+}
+
+func (l *lowerer) pipeline(transforms []ast.Node) Relation {
+	if len(transforms) == 0 {
+		errorf("empty query")
+	}
+	var from, ok = transforms[0].(ast.FromTransform)
+	if !ok {
+		errorf("query must start with from, got %T", transforms[0])
+	}
+
+	l.scan = &Scan{
+		Table:   name(from.Table),
+		Columns: []Column{{ID: l.newID(), Name: "*"}},
+	}
+	if from.Alias != nil {
+		l.scan.Alias = name(*from.Alias)
+	}
+	l.names = map[string]CId{}
+
+	var rel Relation = l.scan
+	for _, node := range transforms[1:] {
+		rel = l.transform(rel, node)
+	}
+	return rel
+}
+
+func (l *lowerer) transform(rel Relation, node ast.Node) Relation {
+	switch node := node.(type) {
+	case ast.DeriveTransform:
+		for _, item := range node.List.Items {
+			rel, _ = l.compute(rel, item, Window{})
+		}
+		return rel
+	case ast.SelectTransform:
+		var columns []Column
+		for _, item := range node.List.Items {
+			if c, ok := item.(ast.Column); ok {
+				columns = append(columns, Column{ID: l.resolve(c.Name), Name: name(c.Name)})
+				continue
+			}
+			var column Column
+			rel, column = l.compute(rel, item, Window{})
+			columns = append(columns, column)
+		}
+
+		l.scan = nil
+		l.projects = nil
+		l.names = map[string]CId{}
+		for _, c := range columns {
+			if c.Name != "" {
+				l.names[c.Name] = c.ID
+			}
+		}
+		return &Project{Input: rel, Columns: columns}
+	case ast.FilterTransform:
+		return &Filter{Input: rel, Cond: l.expr(node.Expr)}
+	case ast.SortTransform:
+		l.sort = l.sortKeys(node.List)
+		return &Sort{Input: rel, Keys: l.sort}
+	case ast.TakeTransform:
+		var offset, limit = rows(node.Expr)
+		return &Take{Input: rel, Limit: limit, Offset: offset}
+	case ast.WindowTransform:
+		return l.window(rel, Window{Sort: l.sort}, node)
+	case ast.GroupTransform:
+		return l.group(rel, Window{}, node)
+	case ast.FromTransform:
+		errorf("from is only supported at the start of a query")
+	default:
+		errorf("unsupported transform %T", node)
+	}
+	return nil
+}
+
+// compute adds the column computed by item to rel, and returns the column.
+// Assignments name the column, and so do plain column references. The
+// aggregate and window functions of item are computed over w.
+func (l *lowerer) compute(rel Relation, item ast.Expr, w Window) (Relation, Column) {
+	var decl Decl
+	switch item := item.(type) {
+	case ast.AssignExpr:
+		decl = Decl{Column: Column{Name: item.Name}, Expr: l.expr(item.Expr)}
+	case ast.Column:
+		decl = Decl{Column: Column{Name: name(item.Name)}, Expr: l.expr(item)}
+	default:
+		decl = Decl{Expr: l.expr(item)}
+	}
+
+	decl.Column.ID = l.newID()
+	if decl.Column.Name != "" {
+		l.names[decl.Column.Name] = decl.Column.ID
+	}
+
+	var aggregate, window = funcs(decl.Expr)
+	switch {
+	case !aggregate && !window:
+		return &Compute{Input: rel, Decl: decl}, decl.Column
+	case !window && len(w.Sort) == 0 && w.Frame == "":
+		// aggregates over all rows of the partitions
+		return l.aggregate(rel, decl, w.Partition), decl.Column
+	}
+	return &Compute{Input: rel, Decl: decl, Window: &w}, decl.Column
+}
+
+// sortKeys returns the keys of a sort transform, which are descending if
+// they are negated.
+func (l *lowerer) sortKeys(list ast.ExprList) []SortKey {
+	var keys = make([]SortKey, len(list.Items))
+	for i, item := range list.Items {
+		if unary, ok := item.(ast.UnaryExpr); ok && (unary.Op == token.SUB || unary.Op == token.ADD) {
+			keys[i] = SortKey{Expr: l.expr(unary.X), Desc: unary.Op == token.SUB}
+		} else {
+			keys[i] = SortKey{Expr: l.expr(item)}
+		}
+	}
+	return keys
+}
+
+// resolve returns the column with the given name. Unknown names are columns
+// of the scanned table while it is in scope.
+func (l *lowerer) resolve(ident ast.Ident) CId {
+	var n = name(ident)
+	if id, ok := l.names[n]; ok {
+		return id
+	}
+	if l.scan == nil {
+		errorf("unknown column %s", n)
+	}
+	var id = l.newID()
+	l.scan.Columns = append(l.scan.Columns, Column{ID: id, Name: n})
+	for _, p := range l.projects {
+		p.Columns = append(p.Columns, Column{ID: id, Name: n})
+	}
+	l.names[n] = id
+	return id
+}
+
+func (l *lowerer) expr(e ast.Expr) Expr {
+	switch e := e.(type) {
+	case ast.Column:
+		return ColumnRef{ID: l.resolve(e.Name)}
+	case ast.Integer, ast.Float, ast.Boolean, ast.Date, ast.Time, ast.Timestamp, ast.Interval:
+		return Literal{Value: e}
+	case ast.String:
+		return l.stringLiteral(e)
+	case ast.ParenExpr:
+		return l.expr(e.X)
+	case ast.UnaryExpr:
+		if e.Op == token.ADD {
+			return l.expr(e.X)
+		}
+		return Unary{X: l.expr(e.X), Op: e.Op}
+	case ast.BinaryExpr:
+		return Binary{X: l.expr(e.X), Y: l.expr(e.Y), Op: e.Op}
+	case ast.CallExpr:
+		var f, ok = stdlib.LookupFunc(e.Name.Name)
+		if !ok {
+			errorf("unknown function %s", e.Name.Name)
+		}
+		var args, err = f.Bind(e)
+		if err != nil {
+			errorf("%v", err)
+		}
+		var call = Call{Name: f.Name, Args: make([]Expr, len(args))}
+		for i, arg := range args {
+			call.Args[i] = l.expr(arg)
+		}
+		return call
+	case ast.CaseExpr:
+		var c = Case{Arms: make([]CaseArm, len(e.Arms))}
+		for i, arm := range e.Arms {
+			c.Arms[i] = CaseArm{Cond: l.expr(arm.Cond), Value: l.expr(arm.Value)}
+		}
+		return c
+	case ast.Array:
+		var array = Array{Items: make([]Expr, len(e.Items))}
+		for i, item := range e.Items {
+			array.Items[i] = l.expr(item)
+		}
+		return array
+	case ast.RangeExpr:
+		var r Range
+		if e.Start != nil {
+			r.Start = l.expr(e.Start)
+		}
+		if e.End != nil {
+			r.End = l.expr(e.End)
+		}
+		return r
+	case ast.AssignExpr:
+		errorf("unexpected assignment to %s", e.Name)
+	default:
+		errorf("unsupported expression %T", e)
+	}
+	return nil
+}
+
+// stringLiteral resolves the columns interpolated in f-strings and
+// s-strings.
+func (l *lowerer) stringLiteral(lit ast.String) Expr {
+	var prefix, value, err = lit.Unquote()
+	if err != nil {
+		errorf("%v", err)
+	}
+	if prefix == 0 {
+		return Literal{Value: lit}
+	}
+
+	var parts, interpolateErr = ast.Interpolate(value)
+	if interpolateErr != nil {
+		errorf("%v", interpolateErr)
+	}
+	var interp = Interpolation{Raw: prefix == 's'}
+	for _, part := range parts {
+		if part.Expr != "" {
+			interp.Parts = append(interp.Parts, ColumnRef{ID: l.resolve(ast.Ident{Name: part.Expr})})
+		} else {
+			interp.Parts = append(interp.Parts, Literal{Value: ast.String{Value: strconv.Quote(part.Text)}})
+		}
+	}
+	return interp
+}
+
+// rows returns the number of rows skipped by take expr, and the number of
+// rows kept after them, or -1 for all of them. The rows of a range are
+// numbered from 1, so 5..10 skips 4 rows and keeps 6.
+func rows(expr ast.Expr) (offset, limit int64) {
+	var r, ok = unparen(expr).(ast.RangeExpr)
+	if !ok {
+		return 0, count(expr)
+	}
+	var start int64 = 1
+	if r.Start != nil {
+		start = count(r.Start)
+	}
+	if start < 1 {
+		errorf("take expects a range starting at 1 or more")
+	}
+	if r.End == nil {
+		return start - 1, -1
+	}
+	var end = count(r.End)
+	if end < start {
+		return start - 1, 0
+	}
+	return start - 1, end - start + 1
+}
+
+func count(expr ast.Expr) int64 {
+	var n, ok = unparen(expr).(ast.Integer)
+	if !ok || n.Value < 0 {
+		errorf("take expects a non-negative integer")
+	}
+	return n.Value
+}
+
+func unparen(expr ast.Expr) ast.Expr {
+	for {
+		if paren, ok := expr.(ast.ParenExpr); ok {
+			expr = paren.X
+		} else {
+			return expr
+		}
+	}
+}
+
+// name returns the name of an identifier without backquotes.
+func name(ident ast.Ident) string {
+	return strings.Trim(ident.Name, "`")
+}
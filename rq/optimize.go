@@ -0,0 +1,343 @@
+package rq
+
+import (
+	"github.com/siadat/prql-parser/simplify"
+	"github.com/siadat/prql-parser/token"
+)
+
+// Pass is an optimizer pass. Run may modify the relations of its argument.
+type Pass struct {
+	Name string
+	Run  func(Relation) Relation
+}
+
+var (
+	// FoldConstants evaluates arithmetic over Integer and Float literals.
+	FoldConstants = Pass{Name: "fold", Run: foldConstants}
+	// MergeProjects replaces a project of a project with the outer one.
+	MergeProjects = Pass{Name: "merge", Run: mergeProjects}
+	// PushFilters moves the conditions of filters below the relations that
+	// do not affect them: computes of other columns without aggregate or
+	// window functions, projects, sorts, and the side of a join that the
+	// condition is about.
+	PushFilters = Pass{Name: "pushdown", Run: pushFilters}
+	// PruneColumns removes computed columns that are not used.
+	PruneColumns = Pass{Name: "prune", Run: pruneColumns}
+)
+
+// Passes lists the optimizer passes in the order Optimize runs them.
+var Passes = []Pass{FoldConstants, MergeProjects, PushFilters, PruneColumns}
+
+// LookupPass returns the pass with the given name.
+func LookupPass(name string) (Pass, bool) {
+	for _, pass := range Passes {
+		if pass.Name == name {
+			return pass, true
+		}
+	}
+	return Pass{}, false
+}
+
+// Optimize runs passes over rel in order, or all Passes if none are given.
+func Optimize(rel Relation, passes ...Pass) Relation {
+	if len(passes) == 0 {
+		passes = Passes
+	}
+	for _, pass := range passes {
+		rel = pass.Run(rel)
+	}
+	return rel
+}
+
+// rewrite replaces the inputs of rel with their rewrites, and then returns
+// the rewrite of rel by f.
+func rewrite(rel Relation, f func(Relation) Relation) Relation {
+	switch r := rel.(type) {
+	case *Project:
+		r.Input = rewrite(r.Input, f)
+	case *Compute:
+		r.Input = rewrite(r.Input, f)
+	case *Filter:
+		r.Input = rewrite(r.Input, f)
+	case *Sort:
+		r.Input = rewrite(r.Input, f)
+	case *Take:
+		r.Input = rewrite(r.Input, f)
+	case *Aggregate:
+		r.Input = rewrite(r.Input, f)
+	case *Join:
+		r.Left = rewrite(r.Left, f)
+		r.Right = rewrite(r.Right, f)
+	}
+	return f(rel)
+}
+
+// rewriteExprs replaces the expressions of rel, but not of its inputs, with
+// their rewrites by f.
+func rewriteExprs(rel Relation, f func(Expr) Expr) {
+	switch r := rel.(type) {
+	case *Compute:
+		r.Decl.Expr = rewriteExpr(r.Decl.Expr, f)
+		if r.Window != nil {
+			var w = *r.Window
+			w.Partition = make([]Expr, len(r.Window.Partition))
+			for i, key := range r.Window.Partition {
+				w.Partition[i] = rewriteExpr(key, f)
+			}
+			w.Sort = rewriteKeys(r.Window.Sort, f)
+			w.Bounds = rewriteExpr(w.Bounds, f).(Range)
+			r.Window = &w
+		}
+	case *Filter:
+		r.Cond = rewriteExpr(r.Cond, f)
+	case *Sort:
+		r.Keys = rewriteKeys(r.Keys, f)
+	case *Aggregate:
+		for i := range r.Decls {
+			r.Decls[i].Expr = rewriteExpr(r.Decls[i].Expr, f)
+		}
+	case *Join:
+		r.On = rewriteExpr(r.On, f)
+	}
+}
+
+// rewriteKeys returns keys with their expressions rewritten by f. Windows
+// may share the keys of a sort, which are therefore not modified.
+func rewriteKeys(keys []SortKey, f func(Expr) Expr) []SortKey {
+	var rewritten = make([]SortKey, len(keys))
+	for i, key := range keys {
+		rewritten[i] = SortKey{Expr: rewriteExpr(key.Expr, f), Desc: key.Desc}
+	}
+	return rewritten
+}
+
+// rewriteExpr replaces the operands of e with their rewrites, and then
+// returns the rewrite of e by f.
+func rewriteExpr(e Expr, f func(Expr) Expr) Expr {
+	switch x := e.(type) {
+	case Binary:
+		x.X = rewriteExpr(x.X, f)
+		x.Y = rewriteExpr(x.Y, f)
+		e = x
+	case Unary:
+		x.X = rewriteExpr(x.X, f)
+		e = x
+	case Call:
+		var args = make([]Expr, len(x.Args))
+		for i, arg := range x.Args {
+			args[i] = rewriteExpr(arg, f)
+		}
+		x.Args = args
+		e = x
+	case Case:
+		var arms = make([]CaseArm, len(x.Arms))
+		for i, arm := range x.Arms {
+			arms[i] = CaseArm{Cond: rewriteExpr(arm.Cond, f), Value: rewriteExpr(arm.Value, f)}
+		}
+		x.Arms = arms
+		e = x
+	case Array:
+		var items = make([]Expr, len(x.Items))
+		for i, item := range x.Items {
+			items[i] = rewriteExpr(item, f)
+		}
+		x.Items = items
+		e = x
+	case Range:
+		if x.Start != nil {
+			x.Start = rewriteExpr(x.Start, f)
+		}
+		if x.End != nil {
+			x.End = rewriteExpr(x.End, f)
+		}
+		e = x
+	}
+	return f(e)
+}
+
+// refs adds the columns referenced by e to ids.
+func refs(e Expr, ids map[CId]bool) map[CId]bool {
+	rewriteExpr(e, func(e Expr) Expr {
+		if ref, ok := e.(ColumnRef); ok {
+			ids[ref.ID] = true
+		}
+		if interp, ok := e.(Interpolation); ok {
+			for _, part := range interp.Parts {
+				refs(part, ids)
+			}
+		}
+		return e
+	})
+	return ids
+}
+
+func foldConstants(rel Relation) Relation {
+	return rewrite(rel, func(rel Relation) Relation {
+		rewriteExprs(rel, fold)
+		return rel
+	})
+}
+
+// fold evaluates negations and arithmetic of numeric literals, as
+// simplify.Fold does. Expressions that cannot be folded exactly are left as
+// they are.
+func fold(e Expr) Expr {
+	switch e := e.(type) {
+	case Unary:
+		if x, ok := e.X.(Literal); ok && e.Op == token.SUB {
+			if neg, ok := simplify.Negate(x.Value); ok {
+				return Literal{Value: neg}
+			}
+		}
+	case Binary:
+		var x, xOK = e.X.(Literal)
+		var y, yOK = e.Y.(Literal)
+		if xOK && yOK {
+			if v, err := simplify.Fold(e.Op, x.Value, y.Value); err == nil && v != nil {
+				return Literal{Value: v}
+			}
+		}
+	}
+	return e
+}
+
+func mergeProjects(rel Relation) Relation {
+	return rewrite(rel, func(rel Relation) Relation {
+		if outer, ok := rel.(*Project); ok {
+			if inner, ok := outer.Input.(*Project); ok {
+				outer.Input = inner.Input
+			}
+		}
+		return rel
+	})
+}
+
+func pushFilters(rel Relation) Relation {
+	return rewrite(rel, func(rel Relation) Relation {
+		if f, ok := rel.(*Filter); ok {
+			return pushFilter(f.Input, f.Cond)
+		}
+		return rel
+	})
+}
+
+// pushFilter returns a relation that filters input by cond, with the
+// condition as far below as possible. Conjunctions are pushed separately.
+func pushFilter(input Relation, cond Expr) Relation {
+	if b, ok := cond.(Binary); ok && b.Op == token.AND {
+		return pushFilter(pushFilter(input, b.X), b.Y)
+	}
+
+	var ids = refs(cond, map[CId]bool{})
+	switch in := input.(type) {
+	case *Compute:
+		// the rows that aggregate and window functions are computed over
+		// must not be filtered
+		if aggregate, window := funcs(in.Decl.Expr); !ids[in.Decl.Column.ID] && !aggregate && !window {
+			in.Input = pushFilter(in.Input, cond)
+			return in
+		}
+	case *Project:
+		in.Input = pushFilter(in.Input, cond)
+		return in
+	case *Sort:
+		in.Input = pushFilter(in.Input, cond)
+		return in
+	case *Join:
+		// the rows of the other side of an outer join must not be filtered
+		if (in.Side == InnerJoin || in.Side == LeftJoin) && subset(ids, in.Left.Output()) {
+			in.Left = pushFilter(in.Left, cond)
+			return in
+		}
+		if (in.Side == InnerJoin || in.Side == RightJoin) && subset(ids, in.Right.Output()) {
+			in.Right = pushFilter(in.Right, cond)
+			return in
+		}
+	}
+	return &Filter{Input: input, Cond: cond}
+}
+
+func subset(ids map[CId]bool, columns []Column) bool {
+	var n = 0
+	for _, c := range columns {
+		if ids[c.ID] {
+			n++
+		}
+	}
+	return n == len(ids)
+}
+
+func pruneColumns(rel Relation) Relation {
+	var needed = map[CId]bool{}
+	for _, c := range rel.Output() {
+		needed[c.ID] = true
+	}
+	return prune(rel, needed)
+}
+
+// prune removes the computes of rel whose columns are not needed by the
+// relations that read rel.
+func prune(rel Relation, needed map[CId]bool) Relation {
+	switch r := rel.(type) {
+	case *Scan:
+		var columns []Column
+		for _, c := range r.Columns {
+			if c.Name == "*" || needed[c.ID] {
+				columns = append(columns, c)
+			}
+		}
+		r.Columns = columns
+	case *Project:
+		var ids = map[CId]bool{}
+		for _, c := range r.Columns {
+			ids[c.ID] = true
+		}
+		r.Input = prune(r.Input, ids)
+	case *Compute:
+		if !needed[r.Decl.Column.ID] {
+			return prune(r.Input, needed)
+		}
+		var ids = refs(r.Decl.Expr, copyIDs(needed))
+		if r.Window != nil {
+			for _, key := range r.Window.Partition {
+				refs(key, ids)
+			}
+			for _, key := range r.Window.Sort {
+				refs(key.Expr, ids)
+			}
+		}
+		r.Input = prune(r.Input, ids)
+	case *Filter:
+		r.Input = prune(r.Input, refs(r.Cond, copyIDs(needed)))
+	case *Sort:
+		var ids = copyIDs(needed)
+		for _, key := range r.Keys {
+			refs(key.Expr, ids)
+		}
+		r.Input = prune(r.Input, ids)
+	case *Take:
+		r.Input = prune(r.Input, needed)
+	case *Aggregate:
+		var ids = map[CId]bool{}
+		for _, c := range r.Group {
+			ids[c.ID] = true
+		}
+		for _, decl := range r.Decls {
+			refs(decl.Expr, ids)
+		}
+		r.Input = prune(r.Input, ids)
+	case *Join:
+		var ids = refs(r.On, copyIDs(needed))
+		r.Left = prune(r.Left, ids)
+		r.Right = prune(r.Right, ids)
+	}
+	return rel
+}
+
+func copyIDs(ids map[CId]bool) map[CId]bool {
+	var c = make(map[CId]bool, len(ids))
+	for id := range ids {
+		c[id] = true
+	}
+	return c
+}
@@ -0,0 +1,266 @@
+package rq
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/siadat/prql-parser/printer"
+	"github.com/siadat/prql-parser/token"
+)
+
+// Fprint writes a textual dump of rel to w, one relation per line followed
+// by its inputs indented below it. Columns are written as name#id.
+func Fprint(w io.Writer, rel Relation) error {
+	var d = &dumper{names: map[CId]string{}}
+	d.collect(rel)
+	d.relation(rel, "")
+	var _, err = io.WriteString(w, d.b.String())
+	return err
+}
+
+type dumper struct {
+	b     strings.Builder
+	names map[CId]string
+}
+
+// collect records the names of the columns declared in rel.
+func (d *dumper) collect(rel Relation) {
+	var declare = func(columns ...Column) {
+		for _, c := range columns {
+			d.names[c.ID] = c.Name
+		}
+	}
+	switch r := rel.(type) {
+	case *Scan:
+		declare(r.Columns...)
+	case *Project:
+		d.collect(r.Input)
+		declare(r.Columns...)
+	case *Compute:
+		d.collect(r.Input)
+		declare(r.Decl.Column)
+	case *Filter:
+		d.collect(r.Input)
+	case *Sort:
+		d.collect(r.Input)
+	case *Take:
+		d.collect(r.Input)
+	case *Aggregate:
+		d.collect(r.Input)
+		declare(r.Group...)
+		for _, decl := range r.Decls {
+			declare(decl.Column)
+		}
+	case *Join:
+		d.collect(r.Left)
+		d.collect(r.Right)
+	}
+}
+
+func (d *dumper) relation(rel Relation, indent string) {
+	d.b.WriteString(indent)
+	var inputs []Relation
+	switch r := rel.(type) {
+	case *Scan:
+		if r.Alias != "" {
+			fmt.Fprintf(&d.b, "scan %s = %s %s", r.Alias, r.Table, d.columns(r.Columns))
+		} else {
+			fmt.Fprintf(&d.b, "scan %s %s", r.Table, d.columns(r.Columns))
+		}
+	case *Project:
+		fmt.Fprintf(&d.b, "project %s", d.columns(r.Columns))
+		inputs = []Relation{r.Input}
+	case *Compute:
+		fmt.Fprintf(&d.b, "compute %s", d.decl(r.Decl))
+		if r.Window != nil {
+			d.b.WriteString(" over " + d.window(*r.Window))
+		}
+		inputs = []Relation{r.Input}
+	case *Filter:
+		fmt.Fprintf(&d.b, "filter %s", d.expr(r.Cond))
+		inputs = []Relation{r.Input}
+	case *Sort:
+		fmt.Fprintf(&d.b, "sort %s", d.sortKeys(r.Keys))
+		inputs = []Relation{r.Input}
+	case *Take:
+		if r.Limit == -1 {
+			d.b.WriteString("take all")
+		} else {
+			fmt.Fprintf(&d.b, "take %d", r.Limit)
+		}
+		if r.Offset > 0 {
+			fmt.Fprintf(&d.b, " offset %d", r.Offset)
+		}
+		inputs = []Relation{r.Input}
+	case *Aggregate:
+		var decls = make([]string, len(r.Decls))
+		for i, decl := range r.Decls {
+			decls[i] = d.decl(decl)
+		}
+		fmt.Fprintf(&d.b, "aggregate by %s [%s]", d.columns(r.Group), strings.Join(decls, ", "))
+		inputs = []Relation{r.Input}
+	case *Join:
+		fmt.Fprintf(&d.b, "join %s on %s", joinSides[r.Side], d.expr(r.On))
+		inputs = []Relation{r.Left, r.Right}
+	default:
+		fmt.Fprintf(&d.b, "%T", rel)
+	}
+	d.b.WriteString("\n")
+
+	for _, input := range inputs {
+		d.relation(input, indent+"  ")
+	}
+}
+
+var joinSides = map[JoinSide]string{
+	InnerJoin: "inner",
+	LeftJoin:  "left",
+	RightJoin: "right",
+	FullJoin:  "full",
+}
+
+// window writes w as (partition [keys] sort [keys] frame bounds), leaving
+// out the parts that are not set.
+func (d *dumper) window(w Window) string {
+	var parts []string
+	if len(w.Partition) > 0 {
+		var keys = make([]string, len(w.Partition))
+		for i, key := range w.Partition {
+			keys[i] = d.expr(key)
+		}
+		parts = append(parts, "partition ["+strings.Join(keys, ", ")+"]")
+	}
+	if len(w.Sort) > 0 {
+		parts = append(parts, "sort "+d.sortKeys(w.Sort))
+	}
+	if w.Frame != "" {
+		parts = append(parts, w.Frame+" "+d.expr(w.Bounds))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func (d *dumper) sortKeys(keys []SortKey) string {
+	var items = make([]string, len(keys))
+	for i, key := range keys {
+		items[i] = d.expr(key.Expr)
+		if key.Desc {
+			items[i] = "-" + items[i]
+		}
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+func (d *dumper) column(id CId) string {
+	return fmt.Sprintf("%s#%d", d.names[id], id)
+}
+
+func (d *dumper) columns(columns []Column) string {
+	var items = make([]string, len(columns))
+	for i, c := range columns {
+		items[i] = d.column(c.ID)
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+func (d *dumper) decl(decl Decl) string {
+	return d.column(decl.Column.ID) + " = " + d.expr(decl.Expr)
+}
+
+var opStrings = map[token.Token]string{
+	token.ADD:    "+",
+	token.SUB:    "-",
+	token.MUL:    "*",
+	token.QUO:    "/",
+	token.EQL:    "==",
+	token.NEQ:    "!=",
+	token.LSS:    "<",
+	token.GTR:    ">",
+	token.LEQ:    "<=",
+	token.GEQ:    ">=",
+	token.AND:    "and",
+	token.OR:     "or",
+	token.REGEX:  "~=",
+	token.INTDIV: "//",
+	token.POW:    "**",
+}
+
+func opString(op token.Token) string {
+	if s, ok := opStrings[op]; ok {
+		return s
+	}
+	return op.String()
+}
+
+// expr writes expressions with parentheses around nested operators, so that
+// the dump does not depend on operator precedence.
+func (d *dumper) expr(e Expr) string {
+	switch e := e.(type) {
+	case ColumnRef:
+		return d.column(e.ID)
+	case Literal:
+		var b strings.Builder
+		if err := printer.Fprint(&b, e.Value); err != nil {
+			return fmt.Sprintf("%T", e.Value)
+		}
+		return b.String()
+	case Binary:
+		return d.operand(e.X) + " " + opString(e.Op) + " " + d.operand(e.Y)
+	case Unary:
+		return opString(e.Op) + d.operand(e.X)
+	case Interpolation:
+		var b strings.Builder
+		if e.Raw {
+			b.WriteString(`s"`)
+		} else {
+			b.WriteString(`f"`)
+		}
+		for _, part := range e.Parts {
+			if ref, ok := part.(ColumnRef); ok {
+				b.WriteString("{" + d.column(ref.ID) + "}")
+			} else {
+				var lit = d.expr(part)
+				b.WriteString(lit[1 : len(lit)-1])
+			}
+		}
+		b.WriteString(`"`)
+		return b.String()
+	case Call:
+		var args = make([]string, len(e.Args))
+		for i, arg := range e.Args {
+			args[i] = d.expr(arg)
+		}
+		return e.Name + "(" + strings.Join(args, ", ") + ")"
+	case Case:
+		var arms = make([]string, len(e.Arms))
+		for i, arm := range e.Arms {
+			arms[i] = d.expr(arm.Cond) + " => " + d.expr(arm.Value)
+		}
+		return "case [" + strings.Join(arms, ", ") + "]"
+	case Array:
+		var items = make([]string, len(e.Items))
+		for i, item := range e.Items {
+			items[i] = d.expr(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case Range:
+		var start, end string
+		if e.Start != nil {
+			start = d.operand(e.Start)
+		}
+		if e.End != nil {
+			end = d.operand(e.End)
+		}
+		return start + ".." + end
+	default:
+		return fmt.Sprintf("%T", e)
+	}
+}
+
+func (d *dumper) operand(e Expr) string {
+	switch e.(type) {
+	case Binary, Unary:
+		return "(" + d.expr(e) + ")"
+	}
+	return d.expr(e)
+}
@@ -0,0 +1,217 @@
+// Package rq defines a relational intermediate representation of PRQL
+// queries, between the AST and the generated SQL.
+//
+// A query is a tree of relations, each reading from its input relations.
+// Columns are identified by a CId that is unique within the query, so that
+// references are resolved once, when the AST is lowered, and remain valid
+// when relations are rewritten.
+//
+// The code generators do not use rq yet: sqlgen translates the AST
+// directly, so the passes of Optimize change the relations that Fprint
+// prints, e.g. for -format=rq, but not the generated SQL.
+package rq
+
+import (
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/token"
+)
+
+// CId identifies a column of a query.
+type CId int
+
+type Column struct {
+	ID   CId
+	Name string // "" for unnamed columns, "*" for all other table columns
+}
+
+// Decl defines a column as the value of an expression.
+type Decl struct {
+	Column Column
+	Expr   Expr
+}
+
+type Relation interface {
+	// Output returns the columns of the relation in order.
+	Output() []Column
+	relation()
+}
+
+type Expr interface {
+	expr()
+}
+
+// Scan reads a table. Columns lists the columns of the table that are
+// referenced, after the "*" column standing for the others.
+type Scan struct {
+	Table   string
+	Alias   string // "" if the table has no alias
+	Columns []Column
+}
+
+// Project keeps the given columns of its input, in the given order.
+type Project struct {
+	Input   Relation
+	Columns []Column
+}
+
+// Compute adds a column to its input. Window is the window of the
+// aggregate and window functions of Decl, nil if it calls none.
+type Compute struct {
+	Input  Relation
+	Decl   Decl
+	Window *Window
+}
+
+type Filter struct {
+	Input Relation
+	Cond  Expr
+}
+
+type SortKey struct {
+	Expr Expr
+	Desc bool
+}
+
+type Sort struct {
+	Input Relation
+	Keys  []SortKey
+}
+
+// Take keeps at most Limit rows, or all rows for -1, after skipping Offset
+// rows.
+type Take struct {
+	Input  Relation
+	Limit  int64
+	Offset int64
+}
+
+// Aggregate groups the rows of its input by the Group columns, and computes
+// Decls for each group.
+type Aggregate struct {
+	Input Relation
+	Group []Column
+	Decls []Decl
+}
+
+type JoinSide int
+
+const (
+	InnerJoin JoinSide = iota
+	LeftJoin
+	RightJoin
+	FullJoin
+)
+
+type Join struct {
+	Left  Relation
+	Right Relation
+	Side  JoinSide
+	On    Expr
+}
+
+// Window is the set of rows that the aggregate and window functions of a
+// Compute are computed over for each row: the rows with the same Partition
+// values, in the order of Sort. Frame is rows or range, and limits the rows
+// of aggregate functions to the offsets of Bounds from the current row. If
+// Frame is "", aggregate functions are computed over the rows up to the
+// current one if Sort is set, and over all rows of the partition otherwise.
+type Window struct {
+	Partition []Expr
+	Sort      []SortKey
+	Frame     string
+	Bounds    Range
+}
+
+// ColumnRef references a column of an input relation.
+type ColumnRef struct {
+	ID CId
+}
+
+// Literal is a literal value, one of the literal expressions of the ast
+// package other than f-strings and s-strings.
+type Literal struct {
+	Value ast.Expr
+}
+
+type Binary struct {
+	X  Expr
+	Y  Expr
+	Op token.Token
+}
+
+type Unary struct {
+	X  Expr
+	Op token.Token
+}
+
+// Interpolation is an f-string, or an s-string if Raw is set. Parts are
+// String literals and column references.
+type Interpolation struct {
+	Raw   bool
+	Parts []Expr
+}
+
+// Call calls a function of the standard library.
+type Call struct {
+	Name string
+	Args []Expr
+}
+
+// Case is the Value of the first arm whose Cond is true, or null.
+type Case struct {
+	Arms []CaseArm
+}
+
+type CaseArm struct {
+	Cond  Expr
+	Value Expr
+}
+
+type Array struct {
+	Items []Expr
+}
+
+// Range is a range of values. Start or End is nil for a range that is
+// unbounded on that side.
+type Range struct {
+	Start Expr
+	End   Expr
+}
+
+func (r *Scan) Output() []Column    { return r.Columns }
+func (r *Project) Output() []Column { return r.Columns }
+func (r *Compute) Output() []Column {
+	return append(append([]Column{}, r.Input.Output()...), r.Decl.Column)
+}
+func (r *Filter) Output() []Column { return r.Input.Output() }
+func (r *Sort) Output() []Column   { return r.Input.Output() }
+func (r *Take) Output() []Column   { return r.Input.Output() }
+func (r *Aggregate) Output() []Column {
+	var columns = append([]Column{}, r.Group...)
+	for _, decl := range r.Decls {
+		columns = append(columns, decl.Column)
+	}
+	return columns
+}
+func (r *Join) Output() []Column {
+	return append(append([]Column{}, r.Left.Output()...), r.Right.Output()...)
+}
+
+func (*Scan) relation()      {}
+func (*Project) relation()   {}
+func (*Compute) relation()   {}
+func (*Filter) relation()    {}
+func (*Sort) relation()      {}
+func (*Take) relation()      {}
+func (*Aggregate) relation() {}
+func (*Join) relation()      {}
+
+func (ColumnRef) expr()     {}
+func (Literal) expr()       {}
+func (Binary) expr()        {}
+func (Unary) expr()         {}
+func (Interpolation) expr() {}
+func (Call) expr()          {}
+func (Case) expr()          {}
+func (Array) expr()         {}
+func (Range) expr()         {}
@@ -0,0 +1,147 @@
+package rq_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/rq"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// TestGolden compares the dump of the relation lowered from
+// testdata/*.prql with the corresponding testdata/*.rq file.
+func TestGolden(tt *testing.T) {
+	var paths, err = filepath.Glob("testdata/*.prql")
+	if err != nil {
+		tt.Fatal(err)
+	}
+
+	for _, path := range paths {
+		var src, err = os.ReadFile(path)
+		if err != nil {
+			tt.Fatal(err)
+		}
+
+		var root, parseErr = parser.NewParser().Parse(strings.NewReader(string(src)))
+		if parseErr != nil {
+			tt.Fatalf("%s: %v", path, parseErr)
+		}
+		var rel, lowerErr = rq.Lower(root)
+		if lowerErr != nil {
+			tt.Fatalf("%s: %v", path, lowerErr)
+		}
+		var b strings.Builder
+		if err := rq.Fprint(&b, rel); err != nil {
+			tt.Fatalf("%s: %v", path, err)
+		}
+		var got = b.String()
+
+		var golden = strings.TrimSuffix(path, ".prql") + ".rq"
+		if *update {
+			if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+				tt.Fatal(err)
+			}
+			continue
+		}
+		var want, readErr = os.ReadFile(golden)
+		if readErr != nil {
+			tt.Fatal(readErr)
+		}
+		if diff := cmp.Diff(string(want), got); diff != "" {
+			tt.Fatalf("mismatching results for %s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", path, diff)
+		}
+	}
+}
+
+func TestErrors(tt *testing.T) {
+	var testCases = []struct {
+		src  string
+		want string
+	}{
+		{
+			src:  `select a`,
+			want: `rq: query must start with from, got ast.SelectTransform`,
+		},
+		{
+			src:  "from t\nselect [a, b = c]\nderive d = c",
+			want: `rq: unknown column c`,
+		},
+		{
+			src:  "from t\ntake a",
+			want: `rq: take expects a non-negative integer`,
+		},
+		{
+			src:  "from t\ngroup a (take 1)",
+			want: `rq: unsupported transform ast.TakeTransform in a window or group pipeline`,
+		},
+		{
+			src:  "from t\nwindow rolling:0 (derive s = sum a)",
+			want: `rq: window rolling expects a positive integer`,
+		},
+		{
+			src:  "from t\ntake 0..2",
+			want: `rq: take expects a range starting at 1 or more`,
+		},
+	}
+
+	for _, tc := range testCases {
+		var root, err = parser.NewParser().Parse(strings.NewReader(tc.src))
+		if err != nil {
+			tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", tc.src, err)
+		}
+		var _, gotErr = rq.Lower(root)
+		if gotErr == nil {
+			tt.Fatalf("expected an error, got nil\nsrc:\n%s", tc.src)
+		}
+		if diff := cmp.Diff(tc.want, gotErr.Error()); diff != "" {
+			tt.Fatalf("mismatching errors\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.src, diff)
+		}
+	}
+}
+
+// TestPasses runs each pass on testdata/passes/<pass>.prql and compares
+// the dumps before and after the pass with testdata/passes/<pass>.rq.
+func TestPasses(tt *testing.T) {
+	for _, pass := range rq.Passes {
+		var path = filepath.Join("testdata", "passes", pass.Name+".prql")
+		var src, err = os.ReadFile(path)
+		if err != nil {
+			tt.Fatal(err)
+		}
+
+		var root, parseErr = parser.NewParser().Parse(strings.NewReader(string(src)))
+		if parseErr != nil {
+			tt.Fatalf("%s: %v", path, parseErr)
+		}
+		var rel, lowerErr = rq.Lower(root)
+		if lowerErr != nil {
+			tt.Fatalf("%s: %v", path, lowerErr)
+		}
+		var b strings.Builder
+		rq.Fprint(&b, rel)
+		b.WriteString("--- " + pass.Name + "\n")
+		rq.Fprint(&b, rq.Optimize(rel, pass))
+		var got = b.String()
+
+		var golden = strings.TrimSuffix(path, ".prql") + ".rq"
+		if *update {
+			if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+				tt.Fatal(err)
+			}
+			continue
+		}
+		var want, readErr = os.ReadFile(golden)
+		if readErr != nil {
+			tt.Fatal(readErr)
+		}
+		if diff := cmp.Diff(string(want), got); diff != "" {
+			tt.Fatalf("mismatching results for %s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", path, diff)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package rq
+
+import (
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/stdlib"
+	"github.com/siadat/prql-parser/token"
+)
+
+// window lowers a window transform, whose frame replaces the frame of w.
+func (l *lowerer) window(rel Relation, w Window, node ast.WindowTransform) Relation {
+	w.Frame, w.Bounds = l.frame(node)
+	return l.nested(rel, w, node.Pipeline)
+}
+
+// group lowers a group transform, whose keys partition the rows of the
+// windows in its pipeline.
+func (l *lowerer) group(rel Relation, w Window, node ast.GroupTransform) Relation {
+	w.Partition = make([]Expr, len(node.By.Items))
+	for i, item := range node.By.Items {
+		w.Partition[i] = l.expr(item)
+	}
+	return l.nested(rel, w, node.Pipeline)
+}
+
+// nested lowers the pipeline of a window or group transform. Sort orders
+// the rows of the window rather than the result, and derive computes its
+// aggregate and window functions over the window.
+func (l *lowerer) nested(rel Relation, w Window, transforms []ast.Node) Relation {
+	for _, node := range transforms {
+		switch node := node.(type) {
+		case ast.DeriveTransform:
+			for _, item := range node.List.Items {
+				rel, _ = l.compute(rel, item, w)
+			}
+		case ast.SortTransform:
+			w.Sort = l.sortKeys(node.List)
+		case ast.WindowTransform:
+			rel = l.window(rel, w, node)
+		case ast.GroupTransform:
+			if len(w.Partition) > 0 {
+				errorf("group is not supported in the pipeline of group")
+			}
+			rel = l.group(rel, w, node)
+		default:
+			errorf("unsupported transform %T in a window or group pipeline", node)
+		}
+	}
+	return rel
+}
+
+// frame returns the frame of the named arguments of a window transform:
+// rows or range, which take a range of offsets from the current row,
+// expanding:true, or rolling:n for the n rows up to the current one.
+func (l *lowerer) frame(node ast.WindowTransform) (string, Range) {
+	if len(node.Named) == 0 {
+		return "", Range{}
+	}
+	if len(node.Named) > 1 {
+		errorf("window expects one of rows, range, expanding and rolling")
+	}
+	var current = Literal{Value: ast.Integer{Value: 0}}
+	switch arg := node.Named[0]; arg.Name.Name {
+	case "rows", "range":
+		var r, ok = arg.Value.(ast.RangeExpr)
+		if !ok {
+			errorf("window %s expects a range, got %T", arg.Name.Name, arg.Value)
+		}
+		return arg.Name.Name, l.expr(r).(Range)
+	case "expanding":
+		var b, ok = unparen(arg.Value).(ast.Boolean)
+		if !ok {
+			errorf("window expanding expects a boolean, got %T", arg.Value)
+		}
+		if b.Value {
+			return "rows", Range{End: current}
+		}
+	case "rolling":
+		var n, ok = unparen(arg.Value).(ast.Integer)
+		if !ok || n.Value < 1 {
+			errorf("window rolling expects a positive integer")
+		}
+		return "rows", Range{Start: Literal{Value: ast.Integer{Value: 1 - n.Value}}, End: current}
+	default:
+		errorf("window has no parameter %s", arg.Name.Name)
+	}
+	return "", Range{}
+}
+
+// funcs reports whether e calls aggregate functions, and whether it calls
+// window functions.
+func funcs(e Expr) (aggregate, window bool) {
+	rewriteExpr(e, func(e Expr) Expr {
+		if call, ok := e.(Call); ok {
+			if f, known := stdlib.LookupFunc(call.Name); known {
+				aggregate = aggregate || f.Aggregate
+				window = window || f.Window
+			}
+		}
+		return e
+	})
+	return aggregate, window
+}
+
+// aggregate adds the column of decl to rel, computing its aggregate
+// functions over the groups of rows with equal keys: the functions are
+// computed by an aggregate, whose rows are joined with the rows of their
+// group, and replaced by the columns of the join. Rows with null keys are
+// in no group.
+//
+// The groups are computed from a copy of rel, so that relations below the
+// join, e.g. a filter pushed down to the rows, do not change them.
+func (l *lowerer) aggregate(rel Relation, decl Decl, keys []Expr) Relation {
+	var ids = map[CId]CId{}
+	var input = l.copy(rel, ids)
+
+	var agg = &Aggregate{}
+	var on Expr = Literal{Value: ast.Boolean{Value: true}}
+	for i, key := range keys {
+		var column = Column{ID: l.newID()}
+		if ref, ok := key.(ColumnRef); ok {
+			column = Column{ID: ids[ref.ID], Name: columnName(input, ids[ref.ID])}
+		} else {
+			input = &Compute{Input: input, Decl: Decl{Column: column, Expr: remap(key, ids)}}
+		}
+		agg.Group = append(agg.Group, column)
+
+		var eq = Binary{X: key, Y: ColumnRef{ID: column.ID}, Op: token.EQL}
+		if i == 0 {
+			on = eq
+		} else {
+			on = Binary{X: on, Y: eq, Op: token.AND}
+		}
+	}
+	agg.Input = input
+
+	decl.Expr = rewriteExpr(decl.Expr, func(e Expr) Expr {
+		if call, ok := e.(Call); ok {
+			if f, known := stdlib.LookupFunc(call.Name); known && f.Aggregate {
+				var column = Column{ID: l.newID(), Name: call.Name}
+				agg.Decls = append(agg.Decls, Decl{Column: column, Expr: remap(call, ids)})
+				return ColumnRef{ID: column.ID}
+			}
+		}
+		return e
+	})
+
+	// the columns of the groups are only read by the compute
+	var join = &Join{Left: rel, Right: agg, Side: InnerJoin, On: on}
+	var project = &Project{
+		Input:   &Compute{Input: join, Decl: decl},
+		Columns: append(append([]Column{}, rel.Output()...), decl.Column),
+	}
+	if l.scan != nil {
+		l.projects = append(l.projects, project)
+	}
+	return project
+}
+
+// columnName returns the name of the column id of rel.
+func columnName(rel Relation, id CId) string {
+	for _, c := range rel.Output() {
+		if c.ID == id {
+			return c.Name
+		}
+	}
+	return ""
+}
+
+// copy returns a copy of rel whose columns have new ids, and adds the new
+// id of each column of rel and its inputs to ids.
+func (l *lowerer) copy(rel Relation, ids map[CId]CId) Relation {
+	var declare = func(c Column) Column {
+		ids[c.ID] = l.newID()
+		return Column{ID: ids[c.ID], Name: c.Name}
+	}
+	var columns = func(cs []Column) []Column {
+		var copied = make([]Column, len(cs))
+		for i, c := range cs {
+			copied[i] = Column{ID: ids[c.ID], Name: c.Name}
+		}
+		return copied
+	}
+	var decls = func(ds []Decl) []Decl {
+		var copied = make([]Decl, len(ds))
+		for i, d := range ds {
+			copied[i] = Decl{Column: declare(d.Column), Expr: remap(d.Expr, ids)}
+		}
+		return copied
+	}
+
+	switch r := rel.(type) {
+	case *Scan:
+		var copied = &Scan{Table: r.Table, Alias: r.Alias, Columns: make([]Column, len(r.Columns))}
+		for i, c := range r.Columns {
+			copied.Columns[i] = declare(c)
+		}
+		return copied
+	case *Project:
+		var input = l.copy(r.Input, ids)
+		return &Project{Input: input, Columns: columns(r.Columns)}
+	case *Compute:
+		var copied = &Compute{Input: l.copy(r.Input, ids)}
+		if r.Window != nil {
+			var w = remapWindow(*r.Window, ids)
+			copied.Window = &w
+		}
+		copied.Decl = decls([]Decl{r.Decl})[0]
+		return copied
+	case *Filter:
+		var input = l.copy(r.Input, ids)
+		return &Filter{Input: input, Cond: remap(r.Cond, ids)}
+	case *Sort:
+		var input = l.copy(r.Input, ids)
+		return &Sort{Input: input, Keys: remapKeys(r.Keys, ids)}
+	case *Take:
+		return &Take{Input: l.copy(r.Input, ids), Limit: r.Limit, Offset: r.Offset}
+	case *Aggregate:
+		var input = l.copy(r.Input, ids)
+		return &Aggregate{Input: input, Group: columns(r.Group), Decls: decls(r.Decls)}
+	case *Join:
+		var left = l.copy(r.Left, ids)
+		var right = l.copy(r.Right, ids)
+		return &Join{Left: left, Right: right, Side: r.Side, On: remap(r.On, ids)}
+	}
+	errorf("unsupported relation %T", rel)
+	return nil
+}
+
+// remap returns e with the columns it references replaced by their ids in
+// ids.
+func remap(e Expr, ids map[CId]CId) Expr {
+	return rewriteExpr(e, func(e Expr) Expr {
+		switch e := e.(type) {
+		case ColumnRef:
+			return ColumnRef{ID: ids[e.ID]}
+		case Interpolation:
+			var parts = make([]Expr, len(e.Parts))
+			for i, part := range e.Parts {
+				parts[i] = remap(part, ids)
+			}
+			e.Parts = parts
+			return e
+		}
+		return e
+	})
+}
+
+func remapKeys(keys []SortKey, ids map[CId]CId) []SortKey {
+	var remapped = make([]SortKey, len(keys))
+	for i, key := range keys {
+		remapped[i] = SortKey{Expr: remap(key.Expr, ids), Desc: key.Desc}
+	}
+	return remapped
+}
+
+func remapWindow(w Window, ids map[CId]CId) Window {
+	var partition = make([]Expr, len(w.Partition))
+	for i, key := range w.Partition {
+		partition[i] = remap(key, ids)
+	}
+	return Window{
+		Partition: partition,
+		Sort:      remapKeys(w.Sort, ids),
+		Frame:     w.Frame,
+		Bounds:    remap(w.Bounds, ids).(Range),
+	}
+}
@@ -61,11 +61,11 @@ func (p *Scanner) SetDebug(debug bool) {
 }
 
 func (s *Scanner) SetSkipWhitespace(v bool) {
-	s.skipWhitespace = true
+	s.skipWhitespace = v
 }
 
 func (s *Scanner) SetSkipComment(v bool) {
-	s.skipComment = true
+	s.skipComment = v
 }
 
 func (s *Scanner) readRune() {
@@ -141,6 +141,14 @@ func (s *Scanner) nextToken() (Token, error) {
 		var tok = Token{token.RBRACK, fmt.Sprintf("%c", s.currRune), Pos(start)}
 		s.readRune()
 		return tok, nil
+	case '{':
+		var tok = Token{token.LBRACE, fmt.Sprintf("%c", s.currRune), Pos(start)}
+		s.readRune()
+		return tok, nil
+	case '}':
+		var tok = Token{token.RBRACE, fmt.Sprintf("%c", s.currRune), Pos(start)}
+		s.readRune()
+		return tok, nil
 	case '(':
 		var tok = Token{token.LPAREN, fmt.Sprintf("%c", s.currRune), Pos(start)}
 		s.readRune()
@@ -158,14 +166,32 @@ func (s *Scanner) nextToken() (Token, error) {
 		s.readRune()
 		return tok, nil
 	case '*':
+		if s.nextRune == '*' {
+			var tok = Token{token.POW, "**", Pos(start)}
+			s.readRune()
+			s.readRune()
+			return tok, nil
+		}
 		var tok = Token{token.MUL, fmt.Sprintf("%c", s.currRune), Pos(start)}
 		s.readRune()
 		return tok, nil
 	case '/':
+		if s.nextRune == '/' {
+			var tok = Token{token.INTDIV, "//", Pos(start)}
+			s.readRune()
+			s.readRune()
+			return tok, nil
+		}
 		var tok = Token{token.QUO, fmt.Sprintf("%c", s.currRune), Pos(start)}
 		s.readRune()
 		return tok, nil
 	case '.':
+		if s.nextRune == '.' {
+			var tok = Token{token.RANGE, "..", Pos(start)}
+			s.readRune()
+			s.readRune()
+			return tok, nil
+		}
 		var tok = Token{token.PERIOD, fmt.Sprintf("%c", s.currRune), Pos(start)}
 		s.readRune()
 		return tok, nil
@@ -193,12 +219,17 @@ func (s *Scanner) nextToken() (Token, error) {
 		s.readRune()
 		return tok, nil
 	case '=':
-		// this can be '=' or '=='
+		// this can be '=', '==' or '=>'
 		if s.nextRune == '=' {
 			var tok = Token{token.EQL, fmt.Sprintf("%c%c", s.currRune, s.nextRune), Pos(start)}
 			s.readRune()
 			s.readRune()
 			return tok, nil
+		} else if s.nextRune == '>' {
+			var tok = Token{token.FATARROW, fmt.Sprintf("%c%c", s.currRune, s.nextRune), Pos(start)}
+			s.readRune()
+			s.readRune()
+			return tok, nil
 		} else {
 			var tok = Token{token.ASSIGN, fmt.Sprintf("%c", s.currRune), Pos(start)}
 			s.readRune()
@@ -240,6 +271,20 @@ func (s *Scanner) nextToken() (Token, error) {
 			s.readRune()
 			return tok, nil
 		}
+	case '~':
+		// this should only be '~='
+		if s.nextRune == '=' {
+			var tok = Token{token.REGEX, fmt.Sprintf("%c%c", s.currRune, s.nextRune), Pos(start)}
+			s.readRune()
+			s.readRune()
+			return tok, nil
+		} else {
+			return Token{
+				token.ILLEGAL,
+				fmt.Sprintf("%c", s.currRune),
+				Pos(start),
+			}, fmt.Errorf("unexpected character %c", s.currRune)
+		}
 	case '?':
 		// this should only be '??'
 		if s.nextRune == '?' {
@@ -378,6 +423,14 @@ func (s *Scanner) readIdentifierUnquoted() (Token, error) {
 		s.readRune()
 	}
 	var lit = string(s.src[position:s.position])
+	switch lit {
+	case "true", "false":
+		return Token{token.BOOLEAN, lit, Pos(position)}, nil
+	case "and":
+		return Token{token.AND, lit, Pos(position)}, nil
+	case "or":
+		return Token{token.OR, lit, Pos(position)}, nil
+	}
 	return Token{token.IDENTIFIER, lit, Pos(position)}, nil
 }
 
@@ -406,12 +459,16 @@ func (s *Scanner) readNumber() (Token, error) {
 	for {
 		switch s.currRune {
 		case '.':
+			if s.nextRune == '.' {
+				// the end of the start of a range, e.g. 1..5
+				return s.number(position, isFloat), nil
+			}
 			isFloat = true
 			s.readRune()
 		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 			s.readRune()
 		default:
-			if s.nextRune >= 'a' && s.nextRune <= 'z' {
+			if s.currRune >= 'a' && s.currRune <= 'z' {
 				var ident, err = s.readIdentifier()
 				if err != nil {
 					return ident, err
@@ -428,23 +485,19 @@ func (s *Scanner) readNumber() (Token, error) {
 				}
 			}
 
-			if isFloat {
-				return Token{
-					token.FLOAT,
-					string(s.src[position:s.position]),
-					Pos(position),
-				}, nil
-			} else {
-				return Token{
-					token.INTEGER,
-					string(s.src[position:s.position]),
-					Pos(position),
-				}, nil
-			}
+			return s.number(position, isFloat), nil
 		}
 	}
 }
 
+// number returns the integer or float that starts at position.
+func (s *Scanner) number(position int, isFloat bool) Token {
+	if isFloat {
+		return Token{token.FLOAT, string(s.src[position:s.position]), Pos(position)}
+	}
+	return Token{token.INTEGER, string(s.src[position:s.position]), Pos(position)}
+}
+
 func (s *Scanner) readDateOrTimeOrDatetime() (Token, error) {
 	var position = s.position
 	for {
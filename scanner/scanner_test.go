@@ -411,6 +411,73 @@ group role (
 				{token.NEWLINE, "\n", IgnorePos},
 			},
 		},
+		{
+			src: `window rows:-3..0 range:..1.5`,
+			want: []scanner.Token{
+				{token.IDENTIFIER, `window`, IgnorePos},
+				{token.WHITESPACE, ` `, IgnorePos},
+				{token.IDENTIFIER, `rows`, IgnorePos},
+				{token.COLON, `:`, IgnorePos},
+				{token.SUB, `-`, IgnorePos},
+				{token.INTEGER, `3`, IgnorePos},
+				{token.RANGE, `..`, IgnorePos},
+				{token.INTEGER, `0`, IgnorePos},
+				{token.WHITESPACE, ` `, IgnorePos},
+				{token.IDENTIFIER, `range`, IgnorePos},
+				{token.COLON, `:`, IgnorePos},
+				{token.RANGE, `..`, IgnorePos},
+				{token.FLOAT, `1.5`, IgnorePos},
+			},
+		},
+		{
+			src: `a//2**b`,
+			want: []scanner.Token{
+				{token.IDENTIFIER, `a`, IgnorePos},
+				{token.INTDIV, `//`, IgnorePos},
+				{token.INTEGER, `2`, IgnorePos},
+				{token.POW, `**`, IgnorePos},
+				{token.IDENTIFIER, `b`, IgnorePos},
+			},
+		},
+		{
+			src: `filter name ~= "^A"`,
+			want: []scanner.Token{
+				{token.IDENTIFIER, `filter`, IgnorePos},
+				{token.WHITESPACE, ` `, IgnorePos},
+				{token.IDENTIFIER, `name`, IgnorePos},
+				{token.WHITESPACE, ` `, IgnorePos},
+				{token.REGEX, `~=`, IgnorePos},
+				{token.WHITESPACE, ` `, IgnorePos},
+				{token.STRING, `"^A"`, IgnorePos},
+			},
+		},
+		{
+			src: `select {a=x, b}`,
+			want: []scanner.Token{
+				{token.IDENTIFIER, `select`, IgnorePos},
+				{token.WHITESPACE, ` `, IgnorePos},
+				{token.LBRACE, `{`, IgnorePos},
+				{token.IDENTIFIER, `a`, IgnorePos},
+				{token.ASSIGN, `=`, IgnorePos},
+				{token.IDENTIFIER, `x`, IgnorePos},
+				{token.COMMA, `,`, IgnorePos},
+				{token.WHITESPACE, ` `, IgnorePos},
+				{token.IDENTIFIER, `b`, IgnorePos},
+				{token.RBRACE, `}`, IgnorePos},
+			},
+		},
+		{
+			src: `case [a=>1]`,
+			want: []scanner.Token{
+				{token.IDENTIFIER, `case`, IgnorePos},
+				{token.WHITESPACE, ` `, IgnorePos},
+				{token.LBRACK, `[`, IgnorePos},
+				{token.IDENTIFIER, `a`, IgnorePos},
+				{token.FATARROW, `=>`, IgnorePos},
+				{token.INTEGER, `1`, IgnorePos},
+				{token.RBRACK, `]`, IgnorePos},
+			},
+		},
 		{
 			src: `
 derive db_version = s"version()"
@@ -626,6 +693,23 @@ select e.first_name
 				{token.RBRACK, `]`, IgnorePos},
 			},
 		},
+		{
+			src: "take 10\nderive x = true\n",
+			want: []scanner.Token{
+				{token.IDENTIFIER, `take`, IgnorePos},
+				{token.WHITESPACE, ` `, IgnorePos},
+				{token.INTEGER, `10`, IgnorePos},
+				{token.NEWLINE, "\n", IgnorePos},
+				{token.IDENTIFIER, `derive`, IgnorePos},
+				{token.WHITESPACE, ` `, IgnorePos},
+				{token.IDENTIFIER, `x`, IgnorePos},
+				{token.WHITESPACE, ` `, IgnorePos},
+				{token.ASSIGN, `=`, IgnorePos},
+				{token.WHITESPACE, ` `, IgnorePos},
+				{token.BOOLEAN, `true`, IgnorePos},
+				{token.NEWLINE, "\n", IgnorePos},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
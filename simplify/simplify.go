@@ -0,0 +1,307 @@
+// Package simplify folds constant arithmetic in PRQL queries.
+package simplify
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/printer"
+	"github.com/siadat/prql-parser/scanner"
+	"github.com/siadat/prql-parser/token"
+)
+
+var (
+	ErrDivisionByZero = errors.New("division by zero")
+	ErrOverflow       = errors.New("integer overflow")
+)
+
+// Diagnostic is a problem found in an expression of the transform or list
+// item at Path. Pos is the position of the operator of the expression.
+type Diagnostic struct {
+	Pos  scanner.Pos
+	Path ast.NodePath
+	Err  error
+	Expr ast.Expr
+}
+
+func (d Diagnostic) Error() string {
+	var b strings.Builder
+	printer.Fprint(&b, d.Expr)
+	if d.Path.Item == -1 {
+		return fmt.Sprintf("transform %d: %v in %s at %d", d.Path.Transform, d.Err, b.String(), d.Pos)
+	}
+	return fmt.Sprintf("transform %d, item %d: %v in %s at %d", d.Path.Transform, d.Path.Item, d.Err, b.String(), d.Pos)
+}
+
+type simplifier struct {
+	path  ast.NodePath
+	items int // number of list items of the transform so far
+	diags []Diagnostic
+}
+
+// Root returns a copy of root in which the expressions of the transforms are
+// simplified by Expr. Division by a constant zero and integer overflow are
+// reported as diagnostics, and the expressions are left unfolded.
+func Root(root *ast.Root) (*ast.Root, []Diagnostic) {
+	var s = &simplifier{}
+	var simplified = &ast.Root{
+		Header:     root.Header,
+		Transforms: make([]ast.Node, len(root.Transforms)),
+		Comments:   root.Comments,
+	}
+	for i, node := range root.Transforms {
+		s.path = ast.NodePath{Transform: i, Item: -1}
+		s.items = 0
+		simplified.Transforms[i] = s.transform(node)
+	}
+	return simplified, s.diags
+}
+
+// Expr returns e with parentheses removed, negated numbers replaced by
+// negative literals and arithmetic over Integer and Float literals folded.
+// The path of the diagnostics is the zero NodePath.
+func Expr(e ast.Expr) (ast.Expr, []Diagnostic) {
+	var s = &simplifier{}
+	return s.expr(e), s.diags
+}
+
+func (s *simplifier) transform(node ast.Node) ast.Node {
+	switch node := node.(type) {
+	case ast.SelectTransform:
+		return ast.SelectTransform{List: s.list(node.List)}
+	case ast.DeriveTransform:
+		return ast.DeriveTransform{List: s.list(node.List)}
+	case ast.SortTransform:
+		return ast.SortTransform{List: s.list(node.List)}
+	case ast.FilterTransform:
+		return ast.FilterTransform{Expr: s.expr(node.Expr)}
+	case ast.TakeTransform:
+		return ast.TakeTransform{Expr: s.expr(node.Expr)}
+	case ast.WindowTransform:
+		var named = make([]ast.NamedArg, len(node.Named))
+		for i, arg := range node.Named {
+			named[i] = ast.NamedArg{Name: arg.Name, Value: s.expr(arg.Value)}
+		}
+		return ast.WindowTransform{Named: named, Pipeline: s.pipeline(node.Pipeline)}
+	case ast.GroupTransform:
+		var by = s.list(node.By)
+		return ast.GroupTransform{By: by, Pipeline: s.pipeline(node.Pipeline)}
+	case ast.CustomTransform:
+		return ast.CustomTransform{Name: node.Name, List: s.list(node.List)}
+	default:
+		return node
+	}
+}
+
+func (s *simplifier) pipeline(transforms []ast.Node) []ast.Node {
+	var simplified = make([]ast.Node, len(transforms))
+	for i, node := range transforms {
+		simplified[i] = s.transform(node)
+	}
+	return simplified
+}
+
+func (s *simplifier) list(list ast.ExprList) ast.ExprList {
+	var items = make([]ast.Expr, len(list.Items))
+	for i, item := range list.Items {
+		s.path.Item = s.items + i
+		items[i] = s.expr(item)
+	}
+	s.path.Item = -1
+	s.items += len(items)
+	return ast.ExprList{Items: items, Braces: list.Braces}
+}
+
+func (s *simplifier) report(err error, e ast.BinaryExpr) {
+	s.diags = append(s.diags, Diagnostic{Pos: e.OpPos, Path: s.path, Err: err, Expr: e})
+}
+
+func (s *simplifier) expr(e ast.Expr) ast.Expr {
+	switch e := e.(type) {
+	case ast.ParenExpr:
+		return s.expr(e.X)
+	case ast.AssignExpr:
+		return ast.AssignExpr{Name: e.Name, Expr: s.expr(e.Expr)}
+	case ast.CallExpr:
+		var call = ast.CallExpr{Name: e.Name, Args: make([]ast.Expr, len(e.Args))}
+		for i, arg := range e.Args {
+			call.Args[i] = s.expr(arg)
+		}
+		for _, arg := range e.Named {
+			call.Named = append(call.Named, ast.NamedArg{Name: arg.Name, Value: s.expr(arg.Value)})
+		}
+		return call
+	case ast.CaseExpr:
+		var arms = make([]ast.CaseArm, len(e.Arms))
+		for i, arm := range e.Arms {
+			arms[i] = ast.CaseArm{Cond: s.expr(arm.Cond), Value: s.expr(arm.Value)}
+		}
+		return ast.CaseExpr{Case: e.Case, Arms: arms}
+	case ast.ExprList:
+		var items = make([]ast.Expr, len(e.Items))
+		for i, item := range e.Items {
+			items[i] = s.expr(item)
+		}
+		return ast.ExprList{Items: items, Braces: e.Braces}
+	case ast.Array:
+		var items = make([]ast.Expr, len(e.Items))
+		for i, item := range e.Items {
+			items[i] = s.expr(item)
+		}
+		return ast.Array{Items: items}
+	case ast.FuncLit:
+		return ast.FuncLit{Params: e.Params, Body: s.expr(e.Body)}
+	case ast.PipeExpr:
+		var pipe = ast.PipeExpr{X: s.expr(e.X), Calls: make([]ast.CallExpr, len(e.Calls))}
+		for i, call := range e.Calls {
+			pipe.Calls[i] = s.expr(call).(ast.CallExpr)
+		}
+		return pipe
+	case ast.RangeExpr:
+		if e.Start != nil {
+			e.Start = s.expr(e.Start)
+		}
+		if e.End != nil {
+			e.End = s.expr(e.End)
+		}
+		return e
+	case ast.UnaryExpr:
+		var x = s.expr(e.X)
+		switch e.Op {
+		case token.ADD:
+			switch x.(type) {
+			case ast.Integer, ast.Float:
+				return x
+			}
+		case token.SUB:
+			if neg, ok := Negate(x); ok {
+				return neg
+			}
+		}
+		return ast.UnaryExpr{X: x, Op: e.Op}
+	case ast.BinaryExpr:
+		var binary = ast.BinaryExpr{X: s.expr(e.X), Y: s.expr(e.Y), Op: e.Op, OpPos: e.OpPos}
+		if (binary.Op == token.QUO || binary.Op == token.INTDIV) && isZero(binary.Y) {
+			s.report(ErrDivisionByZero, e)
+			return binary
+		}
+		var folded, err = Fold(binary.Op, binary.X, binary.Y)
+		if err != nil {
+			s.report(err, e)
+			return binary
+		}
+		if folded != nil {
+			return folded
+		}
+		return binary
+	default:
+		return e
+	}
+}
+
+func isZero(e ast.Expr) bool {
+	switch e := e.(type) {
+	case ast.Integer:
+		return e.Value == 0
+	case ast.Float:
+		return e.Value == 0
+	}
+	return false
+}
+
+// Negate returns the negation of an Integer or Float literal.
+func Negate(x ast.Expr) (ast.Expr, bool) {
+	switch x := x.(type) {
+	case ast.Integer:
+		if x.Value != math.MinInt64 {
+			return ast.Integer{Value: -x.Value}, true
+		}
+	case ast.Float:
+		return ast.Float{Value: -x.Value}, true
+	}
+	return nil, false
+}
+
+// Fold returns the literal value of x op y for the arithmetic operators and
+// Integer or Float literals x and y, or nil if x op y is not folded. The
+// result is an Integer if both x and y are, except for division, which is
+// always a Float as in the generated SQL. Integer division and powers are not
+// folded.
+func Fold(op token.Token, x, y ast.Expr) (ast.Expr, error) {
+	if i, ok := x.(ast.Integer); ok {
+		if j, ok := y.(ast.Integer); ok {
+			return foldInt(op, i.Value, j.Value)
+		}
+	}
+
+	var f, fOK = number(x)
+	var g, gOK = number(y)
+	if !fOK || !gOK {
+		return nil, nil
+	}
+	switch op {
+	case token.ADD:
+		return ast.Float{Value: f + g}, nil
+	case token.SUB:
+		return ast.Float{Value: f - g}, nil
+	case token.MUL:
+		return ast.Float{Value: f * g}, nil
+	case token.QUO:
+		if g == 0 {
+			return nil, ErrDivisionByZero
+		}
+		return ast.Float{Value: f / g}, nil
+	case token.INTDIV:
+		if g == 0 {
+			return nil, ErrDivisionByZero
+		}
+	}
+	return nil, nil
+}
+
+func number(e ast.Expr) (float64, bool) {
+	switch e := e.(type) {
+	case ast.Integer:
+		return float64(e.Value), true
+	case ast.Float:
+		return e.Value, true
+	}
+	return 0, false
+}
+
+func foldInt(op token.Token, x, y int64) (ast.Expr, error) {
+	switch op {
+	case token.ADD:
+		if (y > 0 && x > math.MaxInt64-y) || (y < 0 && x < math.MinInt64-y) {
+			return nil, ErrOverflow
+		}
+		return ast.Integer{Value: x + y}, nil
+	case token.SUB:
+		if (y < 0 && x > math.MaxInt64+y) || (y > 0 && x < math.MinInt64+y) {
+			return nil, ErrOverflow
+		}
+		return ast.Integer{Value: x - y}, nil
+	case token.MUL:
+		if x == 0 || y == 0 {
+			return ast.Integer{Value: 0}, nil
+		}
+		var v = x * y
+		if v/y != x || (x == -1 && y == math.MinInt64) || (y == -1 && x == math.MinInt64) {
+			return nil, ErrOverflow
+		}
+		return ast.Integer{Value: v}, nil
+	case token.QUO:
+		if y == 0 {
+			return nil, ErrDivisionByZero
+		}
+		return ast.Float{Value: float64(x) / float64(y)}, nil
+	case token.INTDIV:
+		if y == 0 {
+			return nil, ErrDivisionByZero
+		}
+	}
+	return nil, nil
+}
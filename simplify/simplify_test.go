@@ -0,0 +1,100 @@
+package simplify_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/printer"
+	"github.com/siadat/prql-parser/simplify"
+)
+
+func TestRoot(tt *testing.T) {
+	var testCases = []struct {
+		src   string
+		want  string
+		diags []string
+	}{
+		{
+			src:  `derive [a = 1 + 2 * 3, b = (((x))), c = -5, d = +1.5, e = -(2 - 0.5), f = 2 * (x + 1)]`,
+			want: "derive [a = 7, b = x, c = -5, d = 1.5, e = -1.5, f = 2 * (x + 1)]\n",
+		},
+		{
+			src:  "from t\nfilter x > 10 * (2 + 1)\nsort [-(1 + a)]\ntake 1 + 1",
+			want: "from t\nfilter x > 30\nsort -(1 + a)\ntake 2\n",
+		},
+		{
+			// division of integers is left to the database
+			src:  `select [7 / 2, 7.0 / 2, 1 - 2.5]`,
+			want: "select [3.5, 3.5, -1.5]\n",
+		},
+		{
+			src:  "derive [a = x // (1 - 1), b = 7 // 2, c = 2 ** 3]",
+			want: "derive [a = x // 0, b = 7 // 2, c = 2 ** 3]\n",
+			diags: []string{
+				"transform 0, item 0: division by zero in x // (1 - 1) at 14",
+			},
+		},
+		{
+			src:  "from t\nderive [a = x / 0, b = 1.0 / (1 - 1)]\nderive [c = 9223372036854775807 + 1, d = -9223372036854775807 - 2]\ntake 3 * 3074457345618258603",
+			want: "from t\nderive [a = x / 0, b = 1.0 / 0]\nderive [c = 9223372036854775807 + 1, d = -9223372036854775807 - 2]\ntake 3 * 3074457345618258603\n",
+			diags: []string{
+				"transform 1, item 0: division by zero in x / 0 at 21",
+				"transform 1, item 1: division by zero in 1.0 / (1 - 1) at 34",
+				"transform 2, item 0: integer overflow in 9223372036854775807 + 1 at 77",
+				"transform 2, item 1: integer overflow in -9223372036854775807 - 2 at 107",
+				"transform 3: integer overflow in 3 * 3074457345618258603 at 119",
+			},
+		},
+		{
+			// items of nested pipelines are numbered after the group keys
+			src:  "from t\ngroup [a, b] (window rows:-(1 + 1)..0 (derive [c = x / 0, d = 1 + 1]))",
+			want: "from t\ngroup [a, b] (window rows:-2..0 (derive [c = x / 0, d = 2]))\n",
+			diags: []string{
+				"transform 1, item 2: division by zero in x / 0 at 60",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		var p = parser.NewParser()
+		var root, err = p.Parse(strings.NewReader(tc.src))
+		if err != nil {
+			tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", tc.src, err)
+		}
+
+		var simplified, diags = simplify.Root(root)
+		var b strings.Builder
+		if err := printer.Fprint(&b, simplified); err != nil {
+			tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", tc.src, err)
+		}
+		if diff := cmp.Diff(tc.want, b.String()); diff != "" {
+			tt.Fatalf("mismatching results\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.src, diff)
+		}
+
+		var gotDiags []string
+		for _, d := range diags {
+			gotDiags = append(gotDiags, d.Error())
+		}
+		if diff := cmp.Diff(tc.diags, gotDiags); diff != "" {
+			tt.Fatalf("mismatching diagnostics\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.src, diff)
+		}
+	}
+}
+
+func TestExpr(tt *testing.T) {
+	var p = parser.NewParser()
+	var expr, err = p.ParseExpr(strings.NewReader(`-(1 + (2)) * 3`))
+	if err != nil {
+		tt.Fatal(err)
+	}
+	var got, diags = simplify.Expr(expr)
+	if diff := cmp.Diff(ast.Expr(ast.Integer{Value: -9}), got); diff != "" {
+		tt.Fatalf("mismatching results\ndiff guide:\n  - want\n  + got\ndiff:\n%s", diff)
+	}
+	if len(diags) != 0 {
+		tt.Fatalf("unexpected diagnostics %v", diags)
+	}
+}
@@ -0,0 +1,288 @@
+package sqlgen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect describes how the SQL of a database differs from the SQL that is
+// common to all supported databases.
+type Dialect interface {
+	// Name is the name of the dialect in the query header, e.g. sqlite in
+	// prql dialect:sqlite
+	Name() string
+
+	// QuoteIdent quotes a single identifier.
+	QuoteIdent(name string) string
+
+	// Limit returns the clauses that keep at most n rows, or all rows for
+	// -1, after skipping offset rows. top follows SELECT and clause ends the
+	// statement; either may be empty.
+	Limit(n, offset int64) (top, clause string)
+
+	// Interval returns the literal for count units, where unit is one of
+	// token.Units.
+	Interval(count int64, unit string) (string, error)
+
+	// DateLiteral returns the literal of type DATE, TIME or TIMESTAMP for
+	// value, which is formatted as YYYY-MM-DD, HH:MM:SS or
+	// YYYY-MM-DD HH:MM:SS respectively.
+	DateLiteral(typ, value string) string
+
+	// Concat returns the concatenation of the string expressions args.
+	Concat(args []string) string
+
+	// Bool returns the literal for value.
+	Bool(value bool) string
+
+	// Regex returns the test of whether the string expression x matches
+	// the regular expression pattern.
+	Regex(x, pattern string) (string, error)
+
+	// Div returns the division of the numeric expressions x and y as
+	// floats, so that 7 / 2 is 3.5 whatever the types of x and y. x and y
+	// are parenthesized like the operands of /.
+	Div(x, y string) string
+
+	// IntDiv returns the quotient of the numeric expressions x and y
+	// truncated to an integer, so that 7 // -2 is -3. x and y are
+	// parenthesized like the operands of /, and so is the result.
+	IntDiv(x, y string) string
+
+	// Length returns the number of characters of the string expression x.
+	Length(x string) string
+
+	// Trim returns the string expression x without the characters of the
+	// string expression chars at its start and end.
+	Trim(x, chars string) string
+
+	// SupportsCTE reports whether queries can be named by WITH clauses.
+	// Otherwise they are nested as subqueries.
+	SupportsCTE() bool
+}
+
+var (
+	Postgres Dialect = postgres{}
+	SQLite   Dialect = sqlite{}
+	MySQL    Dialect = mysql{}
+	DuckDB   Dialect = duckdb{}
+	MySQL57  Dialect = mysql57{}
+)
+
+var dialects = map[string]Dialect{}
+
+func init() {
+	for _, d := range []Dialect{Postgres, SQLite, MySQL, DuckDB, MySQL57} {
+		dialects[d.Name()] = d
+	}
+}
+
+// LookupDialect returns the dialect with the given name.
+func LookupDialect(name string) (Dialect, bool) {
+	var d, ok = dialects[name]
+	return d, ok
+}
+
+type postgres struct{}
+
+func (postgres) Name() string { return "postgres" }
+
+func (postgres) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (postgres) Limit(n, offset int64) (string, string) {
+	if n == -1 {
+		return "", fmt.Sprintf("OFFSET %d", offset)
+	}
+	if offset > 0 {
+		return "", fmt.Sprintf("LIMIT %d OFFSET %d", n, offset)
+	}
+	return "", fmt.Sprintf("LIMIT %d", n)
+}
+
+func (postgres) Interval(count int64, unit string) (string, error) {
+	return fmt.Sprintf("INTERVAL '%d %s'", count, unit), nil
+}
+
+func (postgres) DateLiteral(typ, value string) string {
+	return typ + " '" + value + "'"
+}
+
+func (postgres) Concat(args []string) string {
+	return "CONCAT(" + strings.Join(args, ", ") + ")"
+}
+
+func (postgres) Bool(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func (postgres) SupportsCTE() bool { return true }
+
+func (postgres) Regex(x, pattern string) (string, error) {
+	return x + " ~ " + pattern, nil
+}
+
+// Div converts x to a float, as the division of two integers truncates.
+func (postgres) Div(x, y string) string {
+	return x + " * 1.0 / " + y
+}
+
+func (postgres) IntDiv(x, y string) string {
+	return "DIV(" + x + ", " + y + ")"
+}
+
+func (postgres) Length(x string) string {
+	return "CHAR_LENGTH(" + x + ")"
+}
+
+func (postgres) Trim(x, chars string) string {
+	return "TRIM(" + chars + " FROM " + x + ")"
+}
+
+// duckdb accepts the PostgreSQL syntax, except for intervals, which take
+// the count as a number.
+type duckdb struct {
+	postgres
+}
+
+func (duckdb) Name() string { return "duckdb" }
+
+func (duckdb) Interval(count int64, unit string) (string, error) {
+	return fmt.Sprintf("INTERVAL %d %s", count, singularUnit(unit)), nil
+}
+
+func (duckdb) Div(x, y string) string {
+	return x + " / " + y
+}
+
+func (duckdb) IntDiv(x, y string) string {
+	return x + " // " + y
+}
+
+func (duckdb) Regex(x, pattern string) (string, error) {
+	return "REGEXP_MATCHES(" + x + ", " + pattern + ")", nil
+}
+
+type mysql struct {
+	postgres
+}
+
+func (mysql) Name() string { return "mysql" }
+
+func (mysql) QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// Interval converts milliseconds to microseconds, as MySQL has no
+// MILLISECOND unit.
+func (mysql) Interval(count int64, unit string) (string, error) {
+	if unit == "milliseconds" {
+		return fmt.Sprintf("INTERVAL %d MICROSECOND", count*1000), nil
+	}
+	return fmt.Sprintf("INTERVAL %d %s", count, singularUnit(unit)), nil
+}
+
+// Limit uses the largest limit for all rows, as MySQL has no OFFSET without
+// LIMIT.
+func (d mysql) Limit(n, offset int64) (string, string) {
+	if n == -1 {
+		return "", fmt.Sprintf("LIMIT 18446744073709551615 OFFSET %d", offset)
+	}
+	return d.postgres.Limit(n, offset)
+}
+
+func (mysql) Div(x, y string) string {
+	return x + " / " + y
+}
+
+func (mysql) IntDiv(x, y string) string {
+	return x + " DIV " + y
+}
+
+func (mysql) Regex(x, pattern string) (string, error) {
+	return x + " REGEXP " + pattern, nil
+}
+
+// mysql57 is MySQL before 8.0, which has no common table expressions.
+type mysql57 struct {
+	mysql
+}
+
+func (mysql57) Name() string { return "mysql57" }
+
+func (mysql57) SupportsCTE() bool { return false }
+
+// sqlite has no date, time or boolean types. Dates are strings normalized by
+// the date and time functions, and booleans are integers. The POWER function
+// that ** compiles to needs SQLite 3.35 or later built with the math
+// functions, as the sqlite3 shell is by default.
+type sqlite struct {
+	postgres
+}
+
+func (sqlite) Name() string { return "sqlite" }
+
+func (sqlite) Interval(count int64, unit string) (string, error) {
+	return "", fmt.Errorf("interval %d%s is not supported by sqlite", count, unit)
+}
+
+// Limit uses a negative limit for all rows, as SQLite has no OFFSET without
+// LIMIT.
+func (d sqlite) Limit(n, offset int64) (string, string) {
+	if n == -1 {
+		return "", fmt.Sprintf("LIMIT -1 OFFSET %d", offset)
+	}
+	return d.postgres.Limit(n, offset)
+}
+
+// IntDiv casts the float division to an integer, which truncates it, as
+// SQLite truncates the division of integers only.
+func (sqlite) IntDiv(x, y string) string {
+	return "CAST(" + x + " * 1.0 / " + y + " AS INTEGER)"
+}
+
+func (sqlite) DateLiteral(typ, value string) string {
+	if typ == "TIMESTAMP" {
+		typ = "DATETIME"
+	}
+	return typ + "('" + value + "')"
+}
+
+func (sqlite) Concat(args []string) string {
+	return strings.Join(args, " || ")
+}
+
+func (sqlite) Bool(value bool) string {
+	if value {
+		return "1"
+	}
+	return "0"
+}
+
+// Length counts characters, as LENGTH counts the characters of strings and
+// CHAR_LENGTH does not exist.
+func (sqlite) Length(x string) string {
+	return "LENGTH(" + x + ")"
+}
+
+// Trim passes the characters as the second argument, as SQLite has no
+// TRIM(chars FROM x).
+func (sqlite) Trim(x, chars string) string {
+	return "TRIM(" + x + ", " + chars + ")"
+}
+
+// Regex is not supported, as the REGEXP operator of sqlite calls a function
+// that applications have to define.
+func (sqlite) Regex(x, pattern string) (string, error) {
+	return "", fmt.Errorf("regex search is not supported by sqlite")
+}
+
+// singularUnit returns the SQL name of a unit in token.Units, e.g. DAY for
+// days.
+func singularUnit(unit string) string {
+	return strings.ToUpper(strings.TrimSuffix(unit, "s"))
+}
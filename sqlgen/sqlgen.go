@@ -0,0 +1,806 @@
+// Package sqlgen translates PRQL queries into SELECT statements of one of
+// several SQL dialects. The SQL is generated from the AST rather than from
+// the relations of package rq, so the rq optimizer passes do not apply to it.
+package sqlgen
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/desugar"
+	"github.com/siadat/prql-parser/stdlib"
+	"github.com/siadat/prql-parser/token"
+)
+
+type genError struct {
+	err error
+}
+
+type generator struct {
+	dialect Dialect
+	tables  int   // number of generated relation names
+	over    *over // the window of the transform being translated, nil for all rows
+}
+
+// query is a single SELECT statement.
+type query struct {
+	from     relation
+	star     bool // select all columns of from, followed by columns
+	columns  []column
+	computed map[string]bool // names of the columns computed by columns
+	filter   ast.Expr        // nil for no WHERE clause
+	sort     []string        // ORDER BY keys
+	limit    int64           // -1 for no limit
+	offset   int64           // the number of rows skipped before the limit
+}
+
+// relation is a table, or the result of a query that is named either by a
+// common table expression or by the alias of a subquery.
+type relation struct {
+	name  string
+	alias string
+	query *query // nil for tables
+}
+
+type column struct {
+	expr  string
+	alias string
+	// window is set for columns that call aggregate or window functions,
+	// which are computed after WHERE and before LIMIT
+	window bool
+}
+
+// Generate returns the SQL of the query in the given dialect. If dialect is
+// nil, the dialect named in the query header is used, or Postgres if the
+// query has no header.
+func Generate(root *ast.Root, dialect Dialect) (retSQL string, retErr error) {
+	defer func() {
+		var r = recover()
+		if err, ok := r.(genError); ok {
+			retErr = err.err
+		} else if r != nil {
+			panic(r)
+		}
+	}()
+
+	if dialect == nil {
+		dialect = headerDialect(root.Header)
+	}
+	var g = &generator{dialect: dialect}
+	return g.sql(g.query(desugar.Root(root).Transforms)), nil
+}
+
+// headerDialect returns the dialect named by the dialect argument of the
+// header.
+func headerDialect(header *ast.QueryHeader) Dialect {
+	if header == nil {
+		return Postgres
+	}
+	for _, arg := range header.Args {
+		if arg.Name.Name != "dialect" {
+			continue
+		}
+		var column, ok = arg.Value.(ast.Column)
+		if !ok {
+			errorf("dialect must be a name, got %T", arg.Value)
+		}
+		var dialect, found = LookupDialect(column.Name.Name)
+		if !found {
+			errorf("unknown dialect %q", column.Name.Name)
+		}
+		return dialect
+	}
+	return Postgres
+}
+
+func errorf(format string, args ...interface{}) {
+	panic(genError{fmt.Errorf("sqlgen: "+format, args...)})
+}
+
+func newQuery(from relation) *query {
+	return &query{from: from, star: true, computed: map[string]bool{}, limit: -1}
+}
+
+// query translates the pipeline into a query. A transform that cannot be
+// added to the SELECT statement built so far, e.g. a filter that references
+// a derived column, starts a new query that reads from the previous one.
+func (g *generator) query(transforms []ast.Node) *query {
+	if len(transforms) == 0 {
+		errorf("empty query")
+	}
+	var from, ok = transforms[0].(ast.FromTransform)
+	if !ok {
+		errorf("query must start with from, got %T", transforms[0])
+	}
+
+	var table = relation{name: g.ident(from.Table.Name)}
+	if from.Alias != nil {
+		table.alias = g.ident(from.Alias.Name)
+	}
+	var q = newQuery(table)
+
+	for _, node := range transforms[1:] {
+		switch node := node.(type) {
+		case ast.SelectTransform:
+			for _, item := range node.List.Items {
+				if _, ok := item.(ast.Column); !ok && q.references(item) {
+					q = g.split(q)
+					break
+				}
+			}
+			q.selectColumns(g, node.List)
+		case ast.DeriveTransform:
+			q = g.derive(q, node.List)
+		case ast.FilterTransform:
+			if call, ok := windowCall(node.Expr); ok {
+				errorf("%s is not supported in filter", call.Name.Name)
+			}
+			// a filter after take must not change the rows that are taken,
+			// and a filter after a window must not change its rows
+			if q.taken() || q.windowed() || q.references(node.Expr) {
+				q = g.split(q)
+			}
+			if q.filter == nil {
+				q.filter = node.Expr
+			} else {
+				q.filter = ast.BinaryExpr{X: q.filter, Y: node.Expr, Op: token.AND}
+			}
+		case ast.SortTransform:
+			// rows are sorted before they are taken
+			if q.taken() {
+				q = g.split(q)
+			}
+			// ORDER BY accepts the names of computed columns, but not
+			// expressions of them
+			for _, item := range node.List.Items {
+				if unary, ok := item.(ast.UnaryExpr); ok {
+					item = unary.X
+				}
+				if _, ok := item.(ast.Column); !ok && q.references(item) {
+					q = g.split(q)
+					break
+				}
+			}
+			q.sort = g.sortKeys(node.List)
+		case ast.TakeTransform:
+			q.take(g.rows(node.Expr))
+		case ast.WindowTransform:
+			q = g.window(q, over{order: q.sort}, node)
+		case ast.GroupTransform:
+			q = g.group(q, over{}, node)
+		case ast.FromTransform:
+			errorf("from is only supported at the start of a query")
+		default:
+			errorf("unsupported transform %T", node)
+		}
+	}
+	return q
+}
+
+// taken reports whether q keeps only some of its rows.
+func (q *query) taken() bool {
+	return q.limit != -1 || q.offset > 0
+}
+
+// windowed reports whether q computes columns over windows of its rows.
+func (q *query) windowed() bool {
+	for _, c := range q.columns {
+		if c.window {
+			return true
+		}
+	}
+	return false
+}
+
+// take keeps limit rows of q, or all for -1, after skipping offset rows of
+// those that q already keeps.
+func (q *query) take(offset, limit int64) {
+	if q.limit != -1 {
+		var left = q.limit - offset
+		if left < 0 {
+			left = 0
+		}
+		if limit == -1 || limit > left {
+			limit = left
+		}
+	}
+	q.offset += offset
+	q.limit = limit
+}
+
+// split returns a new query that selects all columns of q. The order of q
+// moves to the new query, as the order of a subquery is not kept, unless
+// it is needed for the limit of q.
+func (g *generator) split(q *query) *query {
+	var name = fmt.Sprintf("table_%d", g.tables)
+	g.tables++
+	var next = newQuery(relation{name: name, query: q})
+	next.sort = q.sort
+	if !q.taken() {
+		q.sort = nil
+	}
+	return next
+}
+
+// sortKeys returns the ORDER BY keys of list.
+func (g *generator) sortKeys(list ast.ExprList) []string {
+	var keys = make([]string, len(list.Items))
+	for i, item := range list.Items {
+		if unary, ok := item.(ast.UnaryExpr); ok && unary.Op == token.SUB {
+			keys[i] = g.expr(unary.X) + " DESC"
+		} else if ok && unary.Op == token.ADD {
+			keys[i] = g.expr(unary.X)
+		} else {
+			keys[i] = g.expr(item)
+		}
+	}
+	return keys
+}
+
+// references reports whether node references a column computed by q.
+func (q *query) references(node ast.Node) bool {
+	var found = false
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case ast.Column:
+			found = found || q.computed[strings.Trim(n.Name.Name, "`")]
+		case ast.String:
+			// f-strings and s-strings reference the interpolated columns
+			var prefix, value, err = n.Unquote()
+			if err != nil || prefix == 0 {
+				break
+			}
+			var parts, _ = ast.Interpolate(value)
+			for _, part := range parts {
+				found = found || q.computed[part.Expr]
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// derive adds the items of list to the columns of q. An item that references
+// a column computed by q, including an earlier item of list, starts a new
+// query, as the columns of a SELECT cannot reference each other. So does an
+// item that calls aggregate or window functions after take, as they are
+// computed before LIMIT.
+func (g *generator) derive(q *query, list ast.ExprList) *query {
+	for _, item := range list.Items {
+		var _, window = windowCall(item)
+		if q.references(item) || window && q.taken() {
+			q = g.split(q)
+		}
+		q.columns = append(q.columns, q.compute(g, ast.ExprList{Items: []ast.Expr{item}})...)
+	}
+	return q
+}
+
+// compute returns the columns of list and records the names of the
+// assigned columns.
+func (q *query) compute(g *generator, list ast.ExprList) []column {
+	var columns = make([]column, len(list.Items))
+	for i, item := range list.Items {
+		var _, window = windowCall(item)
+		if assign, ok := item.(ast.AssignExpr); ok {
+			columns[i] = column{expr: g.expr(assign.Expr), alias: g.ident(assign.Name), window: window}
+			q.computed[assign.Name] = true
+		} else {
+			columns[i] = column{expr: g.expr(item), window: window}
+		}
+	}
+	return columns
+}
+
+// selectColumns replaces the columns of q with list. Computed columns that
+// are selected by name keep their expression.
+func (q *query) selectColumns(g *generator, list ast.ExprList) {
+	var previous = q.columns
+	var computed = q.computed
+	q.star = false
+	q.columns = nil
+	q.computed = map[string]bool{}
+
+	for _, item := range list.Items {
+		var c, ok = item.(ast.Column)
+		var name = ""
+		if ok {
+			name = strings.Trim(c.Name.Name, "`")
+		}
+		if !ok || !computed[name] {
+			q.columns = append(q.columns, q.compute(g, ast.ExprList{Items: []ast.Expr{item}})...)
+			continue
+		}
+		for _, column := range previous {
+			if column.alias == g.ident(name) {
+				q.columns = append(q.columns, column)
+			}
+		}
+		q.computed[name] = true
+	}
+}
+
+// rows returns the rows kept by take expr: the number of rows skipped, and
+// the number of rows kept after them, or -1 for all of them. The rows of a
+// range are numbered from 1, so 5..10 skips 4 rows and keeps 6.
+func (g *generator) rows(expr ast.Expr) (offset, limit int64) {
+	var r, ok = unparen(expr).(ast.RangeExpr)
+	if !ok {
+		return 0, g.count(expr)
+	}
+	var start int64 = 1
+	if r.Start != nil {
+		start = g.count(r.Start)
+	}
+	if start < 1 {
+		errorf("take expects a range starting at 1 or more, got %d", start)
+	}
+	if r.End == nil {
+		return start - 1, -1
+	}
+	var end = g.count(r.End)
+	if end < start {
+		return start - 1, 0
+	}
+	return start - 1, end - start + 1
+}
+
+func (g *generator) count(expr ast.Expr) int64 {
+	expr = unparen(expr)
+	var n, ok = expr.(ast.Integer)
+	if !ok {
+		errorf("take expects an integer, got %T", expr)
+	}
+	if n.Value < 0 {
+		errorf("take expects a non-negative integer, got %d", n.Value)
+	}
+	return n.Value
+}
+
+// sql returns the statement of q. The queries that q reads from become
+// common table expressions, or subqueries if the dialect has no common table
+// expressions.
+func (g *generator) sql(q *query) string {
+	var b strings.Builder
+	if g.dialect.SupportsCTE() {
+		var ctes []relation
+		for r := q.from; r.query != nil; r = r.query.from {
+			ctes = append([]relation{r}, ctes...)
+		}
+		for i, r := range ctes {
+			if i == 0 {
+				b.WriteString("WITH ")
+			} else {
+				b.WriteString(",\n")
+			}
+			b.WriteString(r.name + " AS (\n")
+			b.WriteString(indent(g.selectStatement(r.query), "  "))
+			b.WriteString(")")
+		}
+		if len(ctes) > 0 {
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString(g.selectStatement(q))
+	return b.String()
+}
+
+func (g *generator) selectStatement(q *query) string {
+	var items []string
+	if q.star {
+		items = append(items, "*")
+	}
+	for _, c := range q.columns {
+		if c.alias != "" && c.alias != c.expr {
+			items = append(items, c.expr+" AS "+c.alias)
+		} else {
+			items = append(items, c.expr)
+		}
+	}
+
+	var from = q.from.name
+	if q.from.query != nil && !g.dialect.SupportsCTE() {
+		from = "(\n" + indent(g.selectStatement(q.from.query), "  ") + ") AS " + q.from.name
+	} else if q.from.alias != "" {
+		from += " AS " + q.from.alias
+	}
+
+	var top, clause string
+	if q.taken() {
+		top, clause = g.dialect.Limit(q.limit, q.offset)
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT")
+	if top != "" {
+		b.WriteString(" " + top)
+	}
+	b.WriteString("\n  ")
+	b.WriteString(strings.Join(items, ",\n  "))
+	b.WriteString("\nFROM\n  ")
+	b.WriteString(strings.ReplaceAll(from, "\n", "\n  "))
+	b.WriteString("\n")
+	if q.filter != nil {
+		b.WriteString("WHERE\n  " + g.expr(q.filter) + "\n")
+	}
+	if len(q.sort) > 0 {
+		b.WriteString("ORDER BY\n  " + strings.Join(q.sort, ",\n  ") + "\n")
+	}
+	if clause != "" {
+		b.WriteString(clause + "\n")
+	}
+	return b.String()
+}
+
+// indent prefixes each line of s with prefix.
+func indent(s, prefix string) string {
+	var lines = strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+var plainIdent = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// ident quotes an identifier unless it is a lower-case name that is not a
+// keyword. Identifiers may be qualified by a relation, e.g. e.id or
+// `table.column`, whose parts are quoted separately.
+func (g *generator) ident(name string) string {
+	name = strings.Trim(name, "`")
+	if strings.Contains(name, ".") {
+		var parts = strings.Split(name, ".")
+		for i, part := range parts {
+			parts[i] = g.ident(part)
+		}
+		return strings.Join(parts, ".")
+	}
+	if plainIdent.MatchString(name) && !keywords[name] {
+		return name
+	}
+	return g.dialect.QuoteIdent(name)
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+var binaryOps = map[token.Token]string{
+	token.ADD: "+",
+	token.SUB: "-",
+	token.MUL: "*",
+	token.EQL: "=",
+	token.NEQ: "<>",
+	token.LSS: "<",
+	token.GTR: ">",
+	token.LEQ: "<=",
+	token.GEQ: ">=",
+	token.AND: "AND",
+	token.OR:  "OR",
+}
+
+func (g *generator) expr(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case ast.Column:
+		return g.ident(expr.Name.Name)
+	case ast.Integer:
+		return strconv.FormatInt(expr.Value, 10)
+	case ast.Float:
+		var s = strconv.FormatFloat(expr.Value, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	case ast.Boolean:
+		return g.dialect.Bool(expr.Value)
+	case ast.String:
+		return g.stringLiteral(expr)
+	case ast.Date:
+		return g.dialect.DateLiteral("DATE", fmt.Sprintf("%04d-%02d-%02d", expr.Year, expr.Month, expr.Day))
+	case ast.Time:
+		return g.dialect.DateLiteral("TIME", fmt.Sprintf("%02d:%02d:%02d", expr.Hour, expr.Minute, expr.Second))
+	case ast.Timestamp:
+		return g.dialect.DateLiteral("TIMESTAMP", fmt.Sprintf("%04d-%02d-%02d %02d:%02d:%02d", expr.Year, expr.Month, expr.Day, expr.Hour, expr.Minute, expr.Second))
+	case ast.Interval:
+		var interval, err = g.dialect.Interval(expr.Count, expr.Unit)
+		if err != nil {
+			errorf("%v", err)
+		}
+		return interval
+	case ast.ParenExpr:
+		// parentheses are added where needed by the operator precedence
+		return g.expr(expr.X)
+	case ast.UnaryExpr:
+		var x = g.expr(expr.X)
+		// a negated negation or negative number would start a -- comment
+		if _, ok := unparen(expr.X).(ast.BinaryExpr); ok || strings.HasPrefix(x, "-") {
+			x = "(" + x + ")"
+		}
+		switch expr.Op {
+		case token.SUB:
+			return "-" + x
+		case token.ADD:
+			return x
+		}
+		errorf("unsupported unary operator %s", expr.Op)
+		return ""
+	case ast.BinaryExpr:
+		if expr.Op == token.POW {
+			return "POWER(" + g.expr(expr.X) + ", " + g.expr(expr.Y) + ")"
+		}
+		var prec = token.Precedences[expr.Op]
+		var x = g.expr(expr.X)
+		var y = g.expr(expr.Y)
+		// SQL operators group to the left, so the right operand keeps its
+		// parentheses when it has the same precedence
+		if xPrec, ok := binaryPrecedence(expr.X); ok && xPrec < prec {
+			x = "(" + x + ")"
+		}
+		if yPrec, ok := binaryPrecedence(expr.Y); ok && yPrec <= prec {
+			y = "(" + y + ")"
+		}
+		switch expr.Op {
+		case token.REGEX:
+			var sql, err = g.dialect.Regex(x, y)
+			if err != nil {
+				errorf("%v", err)
+			}
+			return sql
+		case token.QUO:
+			return g.dialect.Div(x, y)
+		case token.INTDIV:
+			return g.dialect.IntDiv(x, y)
+		}
+		var op, ok = binaryOps[expr.Op]
+		if !ok {
+			errorf("unsupported binary operator %s", expr.Op)
+		}
+		return x + " " + op + " " + y
+	case ast.CallExpr:
+		return g.call(expr)
+	case ast.CaseExpr:
+		var b strings.Builder
+		b.WriteString("CASE")
+		for i, arm := range expr.Arms {
+			// an arm that is always taken is the ELSE of the arms before it
+			var cond, ok = unparen(arm.Cond).(ast.Boolean)
+			if ok && cond.Value && i > 0 {
+				b.WriteString(" ELSE " + g.expr(arm.Value))
+				break
+			}
+			b.WriteString(" WHEN " + g.expr(arm.Cond) + " THEN " + g.expr(arm.Value))
+			if ok && cond.Value {
+				break
+			}
+		}
+		b.WriteString(" END")
+		return b.String()
+	case ast.AssignExpr:
+		errorf("unexpected assignment to %s", expr.Name)
+		return ""
+	default:
+		errorf("unsupported expression %T", expr)
+		return ""
+	}
+}
+
+// call renders the SQL template of the function with the arguments of
+// call. Aggregate and window functions are computed over g.over.
+func (g *generator) call(call ast.CallExpr) string {
+	var f, args = bind(call)
+	switch f.Name {
+	case "in":
+		return g.in(args[0], args[1])
+	case "text.contains", "text.starts_with", "text.ends_with":
+		return g.like(f.Name, args[0], args[1])
+	case "length":
+		return g.dialect.Length(g.expr(args[0]))
+	case "trim":
+		return g.dialect.Trim(g.expr(args[0]), g.expr(args[1]))
+	}
+	var pairs []string
+	for i, arg := range args {
+		pairs = append(pairs, "{"+f.Param(i).Name+"}", g.expr(arg))
+	}
+	var sql = strings.NewReplacer(pairs...).Replace(f.SQL)
+	if f.Aggregate || f.Window {
+		sql += " " + g.over.clause(f.Aggregate)
+	}
+	return sql
+}
+
+// in renders a membership test: IN for an array pattern, and BETWEEN, or a
+// comparison for ranges with a single bound, for a range pattern.
+func (g *generator) in(pattern, value ast.Expr) string {
+	var x = g.expr(value)
+	if _, ok := binaryPrecedence(value); ok {
+		x = "(" + x + ")"
+	}
+	switch pattern := unparen(pattern).(type) {
+	case ast.Array:
+		if len(pattern.Items) == 0 {
+			errorf("in expects at least one value")
+		}
+		var items = make([]string, len(pattern.Items))
+		for i, item := range pattern.Items {
+			items[i] = g.expr(item)
+		}
+		return x + " IN (" + strings.Join(items, ", ") + ")"
+	case ast.RangeExpr:
+		switch {
+		case pattern.Start != nil && pattern.End != nil:
+			return x + " BETWEEN " + g.expr(pattern.Start) + " AND " + g.expr(pattern.End)
+		case pattern.Start != nil:
+			return x + " >= " + g.expr(pattern.Start)
+		case pattern.End != nil:
+			return x + " <= " + g.expr(pattern.End)
+		}
+		errorf("in expects a bounded range")
+	}
+	errorf("in expects an array or a range, got %T", pattern)
+	return ""
+}
+
+// likeEscape is the escape character of LIKE patterns. It is not a
+// backslash, which MySQL also treats as an escape in string literals.
+const likeEscape = "!"
+
+// likeEscaper escapes the wildcards of LIKE patterns in plain strings.
+var likeEscaper = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+
+// like renders the text function name as a LIKE test of value, with
+// wildcards around the searched string s where the function allows other
+// characters. The wildcards % and _ in s match themselves.
+func (g *generator) like(name string, s, value ast.Expr) string {
+	var x = g.expr(value)
+	if _, ok := binaryPrecedence(value); ok {
+		x = "(" + x + ")"
+	}
+	var before, after = name != "text.starts_with", name != "text.ends_with"
+
+	// plain strings are searched with a single literal
+	if lit, ok := unparen(s).(ast.String); ok {
+		if prefix, text, err := lit.Unquote(); err == nil && prefix == 0 {
+			var escaped = likeEscaper.Replace(text)
+			var pattern = escaped
+			if before {
+				pattern = "%" + pattern
+			}
+			if after {
+				pattern += "%"
+			}
+			if escaped == text {
+				return x + " LIKE " + quoteString(pattern)
+			}
+			return x + " LIKE " + quoteString(pattern) + " ESCAPE " + quoteString(likeEscape)
+		}
+	}
+
+	var escaped = g.expr(s)
+	for _, c := range []string{likeEscape, "%", "_"} {
+		escaped = "REPLACE(" + escaped + ", " + quoteString(c) + ", " + quoteString(likeEscape+c) + ")"
+	}
+	var parts = []string{escaped}
+	if before {
+		parts = append([]string{"'%'"}, parts...)
+	}
+	if after {
+		parts = append(parts, "'%'")
+	}
+	return x + " LIKE " + g.dialect.Concat(parts) + " ESCAPE " + quoteString(likeEscape)
+}
+
+func bind(call ast.CallExpr) (*stdlib.Func, []ast.Expr) {
+	var f, ok = stdlib.LookupFunc(call.Name.Name)
+	if !ok {
+		errorf("unknown function %s", call.Name.Name)
+	}
+	var args, err = f.Bind(call)
+	if err != nil {
+		errorf("%v", err)
+	}
+	return f, args
+}
+
+// stringLiteral renders plain strings as SQL strings, s-strings as raw SQL
+// and f-strings as concatenations.
+func (g *generator) stringLiteral(lit ast.String) string {
+	var prefix, value, err = lit.Unquote()
+	if err != nil {
+		errorf("%v", err)
+	}
+	if prefix == 0 {
+		return quoteString(value)
+	}
+
+	var parts, interpolateErr = ast.Interpolate(value)
+	if interpolateErr != nil {
+		errorf("%v", interpolateErr)
+	}
+
+	var items = make([]string, len(parts))
+	for i, part := range parts {
+		switch {
+		case part.Expr != "":
+			items[i] = g.ident(part.Expr)
+		case prefix == 's':
+			items[i] = part.Text
+		default:
+			items[i] = quoteString(part.Text)
+		}
+	}
+	if prefix == 's' {
+		return strings.Join(items, "")
+	}
+	return g.dialect.Concat(items)
+}
+
+// windowCall returns the first call of an aggregate or window function in
+// expr.
+func windowCall(expr ast.Expr) (ast.CallExpr, bool) {
+	var found ast.CallExpr
+	var ok = false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if call, isCall := n.(ast.CallExpr); isCall && !ok {
+			if f, known := stdlib.LookupFunc(call.Name.Name); known && (f.Aggregate || f.Window) {
+				found, ok = call, true
+			}
+		}
+		return !ok
+	})
+	return found, ok
+}
+
+func unparen(expr ast.Expr) ast.Expr {
+	for {
+		if paren, ok := expr.(ast.ParenExpr); ok {
+			expr = paren.X
+		} else {
+			return expr
+		}
+	}
+}
+
+// comparisons are the functions that are rendered as SQL comparisons.
+var comparisons = map[string]bool{
+	"in":               true,
+	"text.contains":    true,
+	"text.starts_with": true,
+	"text.ends_with":   true,
+}
+
+func binaryPrecedence(expr ast.Expr) (token.Precedence, bool) {
+	// POWER is a function call, which needs no parentheses
+	if binary, ok := unparen(expr).(ast.BinaryExpr); ok && binary.Op != token.POW {
+		return token.Precedences[binary.Op], true
+	}
+	// IN, BETWEEN and LIKE bind like comparisons
+	if call, ok := unparen(expr).(ast.CallExpr); ok && comparisons[call.Name.Name] {
+		return token.Precedences[token.EQL], true
+	}
+	return 0, false
+}
+
+// keywords are the reserved keywords of PostgreSQL, which are quoted when
+// used as identifiers in all dialects.
+var keywords = map[string]bool{}
+
+func init() {
+	for _, k := range strings.Fields(`
+		all analyse analyze and any array as asc asymmetric both case cast
+		check collate column constraint create current_catalog current_date
+		current_role current_time current_timestamp current_user default
+		deferrable desc distinct do else end except false fetch for foreign
+		from grant group having in initially intersect into lateral leading
+		limit localtime localtimestamp not null offset on only or order
+		placing primary references returning select session_user some
+		symmetric table then to trailing true union unique user using
+		variadic when where window with`) {
+		keywords[k] = true
+	}
+}
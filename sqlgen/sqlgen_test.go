@@ -0,0 +1,179 @@
+package sqlgen_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/sqlgen"
+	"github.com/siadat/prql-parser/token"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// TestGolden compares the SQL generated for testdata/*.prql with the
+// corresponding testdata/*.sql file.
+func TestGolden(tt *testing.T) {
+	var paths, err = filepath.Glob("testdata/*.prql")
+	if err != nil {
+		tt.Fatal(err)
+	}
+
+	for _, path := range paths {
+		var src, err = os.ReadFile(path)
+		if err != nil {
+			tt.Fatal(err)
+		}
+
+		var root, parseErr = parser.NewParser().Parse(strings.NewReader(string(src)))
+		if parseErr != nil {
+			tt.Fatalf("%s: %v", path, parseErr)
+		}
+		var got, genErr = sqlgen.Generate(root, nil)
+		if genErr != nil {
+			tt.Fatalf("%s: %v", path, genErr)
+		}
+
+		var golden = strings.TrimSuffix(path, ".prql") + ".sql"
+		if *update {
+			if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+				tt.Fatal(err)
+			}
+			continue
+		}
+		var want, readErr = os.ReadFile(golden)
+		if readErr != nil {
+			tt.Fatal(readErr)
+		}
+		if diff := cmp.Diff(string(want), got); diff != "" {
+			tt.Fatalf("mismatching results for %s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", path, diff)
+		}
+	}
+}
+
+func TestErrors(tt *testing.T) {
+	var testCases = []struct {
+		src  string
+		want string
+	}{
+		{
+			src:  `select a`,
+			want: `sqlgen: query must start with from, got ast.SelectTransform`,
+		},
+		{
+			src:  "from a\nfrom b",
+			want: `sqlgen: from is only supported at the start of a query`,
+		},
+		{
+			src:  "from a\nderive b = f\"{c\"",
+			want: `sqlgen: unclosed interpolation in "{c"`,
+		},
+		{
+			src:  "prql dialect:oracle\nfrom a",
+			want: `sqlgen: unknown dialect "oracle"`,
+		},
+		{
+			src:  "prql dialect:sqlite\nfrom a\nderive b = c + 1days",
+			want: `sqlgen: interval 1days is not supported by sqlite`,
+		},
+		{
+			src:  "from a\ntake b",
+			want: `sqlgen: take expects an integer, got ast.Column`,
+		},
+		{
+			src:  "from a\ntake 0..5",
+			want: `sqlgen: take expects a range starting at 1 or more, got 0`,
+		},
+		{
+			src:  "from a\ntake 1..(b)",
+			want: `sqlgen: take expects an integer, got ast.Column`,
+		},
+		{
+			src:  "from a\nfilter (sum b) > 10",
+			want: `sqlgen: sum is not supported in filter`,
+		},
+		{
+			src:  "from a\nwindow rows:-1.5..0 (derive b = sum c)",
+			want: `sqlgen: window rows expects integer bounds, got ast.Float`,
+		},
+		{
+			src:  "from a\nwindow rows:-1..0 expanding:true (derive b = sum c)",
+			want: `sqlgen: window expects one of rows, range, expanding and rolling`,
+		},
+		{
+			src:  "from a\nfilter in [] b",
+			want: `sqlgen: in expects at least one value`,
+		},
+		{
+			src:  "from a\nfilter in c b",
+			want: `sqlgen: in expects an array or a range, got ast.Column`,
+		},
+		{
+			src:  "prql dialect:sqlite\nfrom a\nfilter b ~= \"^x\"",
+			want: `sqlgen: regex search is not supported by sqlite`,
+		},
+		{
+			src:  "from a\nderive f = x -> x + 1",
+			want: `sqlgen: unsupported expression ast.FuncLit`,
+		},
+		{
+			src:  "from a\ngroup b (take 1)",
+			want: `sqlgen: unsupported transform ast.TakeTransform in a window or group pipeline`,
+		},
+	}
+
+	for _, tc := range testCases {
+		var root, err = parser.NewParser().Parse(strings.NewReader(tc.src))
+		if err != nil {
+			tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", tc.src, err)
+		}
+		var _, gotErr = sqlgen.Generate(root, nil)
+		if gotErr == nil {
+			tt.Fatalf("expected an error, got nil\nsrc:\n%s", tc.src)
+		}
+		if diff := cmp.Diff(tc.want, gotErr.Error()); diff != "" {
+			tt.Fatalf("mismatching errors\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.src, diff)
+		}
+	}
+}
+
+func TestDialectOverride(tt *testing.T) {
+	var root, err = parser.NewParser().Parse(strings.NewReader("prql dialect:postgres\nfrom `Order`\ntake 1"))
+	if err != nil {
+		tt.Fatal(err)
+	}
+	var got, genErr = sqlgen.Generate(root, sqlgen.MySQL)
+	if genErr != nil {
+		tt.Fatal(genErr)
+	}
+	var want = "SELECT\n  *\nFROM\n  `Order`\nLIMIT 1\n"
+	if diff := cmp.Diff(want, got); diff != "" {
+		tt.Fatalf("mismatching results\ndiff guide:\n  - want\n  + got\ndiff:\n%s", diff)
+	}
+}
+
+// TestNegatedLiteral checks that negative numbers, e.g. folded by
+// simplify.Root, are parenthesized when negated, as --1 starts a comment.
+func TestNegatedLiteral(tt *testing.T) {
+	var root = &ast.Root{
+		Transforms: []ast.Node{
+			ast.FromTransform{Table: ast.Ident{Name: "t"}},
+			ast.DeriveTransform{List: ast.ExprList{Items: []ast.Expr{
+				ast.AssignExpr{Name: "x", Expr: ast.UnaryExpr{X: ast.Integer{Value: -1}, Op: token.SUB}},
+			}}},
+		},
+	}
+	var got, err = sqlgen.Generate(root, nil)
+	if err != nil {
+		tt.Fatal(err)
+	}
+	var want = "SELECT\n  *,\n  -(-1) AS x\nFROM\n  t\n"
+	if diff := cmp.Diff(want, got); diff != "" {
+		tt.Fatalf("mismatching results\ndiff guide:\n  - want\n  + got\ndiff:\n%s", diff)
+	}
+}
@@ -0,0 +1,16 @@
+SELECT
+  *,
+  paid * 1.0 / total AS ratio,
+  DIV(total, page_size) AS pages,
+  DIV(-7, 2) AS negative,
+  DIV(balance, -2) AS halved,
+  DIV((total + 1), page_size) AS rows,
+  POWER(width, 2) AS area,
+  POWER(2, POWER(n, 2)) AS tower,
+  price * POWER(1 + rate, years) AS grown,
+  DIV(POWER(a - b, 2), 3) AS squared,
+  -(-1) AS positive,
+  -(-balance) AS restored,
+  -balance AS kept
+FROM
+  "Orders"
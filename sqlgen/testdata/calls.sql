@@ -0,0 +1,21 @@
+WITH table_0 AS (
+  SELECT
+    *,
+    SUM(amount) OVER () AS total,
+    amount * 1.0 / SUM(amount) OVER () AS share,
+    LAG(amount, 1) OVER () AS previous,
+    ROUND(amount * 1.1, 2) AS rounded
+  FROM
+    orders
+)
+SELECT
+  order_id,
+  total,
+  share,
+  previous,
+  rounded,
+  UPPER(TRIM('-' FROM code)) AS code
+FROM
+  table_0
+WHERE
+  CHAR_LENGTH(TRIM(' ' FROM name)) > 0
@@ -0,0 +1,8 @@
+SELECT
+  *,
+  CASE WHEN score > 90 THEN 'A' WHEN score > 80 THEN 'B' ELSE 'C' END AS grade,
+  salary * CASE WHEN level = 1 THEN 0.1 + 0.05 END AS bonus
+FROM
+  t
+WHERE
+  CASE WHEN TRUE THEN active END
@@ -0,0 +1,27 @@
+WITH table_0 AS (
+  SELECT
+    *,
+    1 AS a
+  FROM
+    t
+),
+table_1 AS (
+  SELECT
+    *,
+    a + 1 AS b,
+    x * 2 AS c
+  FROM
+    table_0
+),
+table_2 AS (
+  SELECT
+    *,
+    SUM(b) OVER (PARTITION BY k) AS s
+  FROM
+    table_1
+)
+SELECT
+  *,
+  b * 1.0 / s AS share
+FROM
+  table_2
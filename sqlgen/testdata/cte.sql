@@ -0,0 +1,32 @@
+WITH table_0 AS (
+  SELECT
+    *,
+    salary + bonus AS gross,
+    CONCAT(first_name, ' ', last_name) AS label
+  FROM
+    employees AS e
+),
+table_1 AS (
+  SELECT
+    *,
+    gross * 0.8 AS net
+  FROM
+    table_0
+),
+table_2 AS (
+  SELECT
+    label,
+    net,
+    gross - net AS tax
+  FROM
+    table_1
+  WHERE
+    net > 1000
+  LIMIT 10
+)
+SELECT
+  *
+FROM
+  table_2
+WHERE
+  tax > 100
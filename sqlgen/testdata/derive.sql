@@ -0,0 +1,9 @@
+SELECT
+  *,
+  salary + payroll_tax AS gross_salary,
+  (salary + benefits) * -1 AS gross_cost,
+  1 - (a - b) AS ratio,
+  1 - a - b AS nested,
+  a - b - c AS chained
+FROM
+  employees
@@ -0,0 +1,14 @@
+SELECT
+  *,
+  created_at + INTERVAL 10 DAY AS due,
+  created_at + INTERVAL 500 MILLISECOND AS soon,
+  DATE '2022-12-31' AS d,
+  TIMESTAMP '2022-12-31 01:02:03' AS ts,
+  CONCAT('order ', id) AS label,
+  TRUE AS active,
+  REGEXP_MATCHES(name, '^A.*') AS matches,
+  paid / total AS ratio,
+  balance // -2 AS halved
+FROM
+  "Orders"
+LIMIT 10
@@ -0,0 +1,7 @@
+SELECT
+  first_name,
+  age
+FROM
+  employees
+WHERE
+  age > 30 AND country = 'US' AND (salary >= 1000 OR bonus <> 0)
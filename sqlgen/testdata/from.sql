@@ -0,0 +1,4 @@
+SELECT
+  *
+FROM
+  employees
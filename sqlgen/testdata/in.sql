@@ -0,0 +1,8 @@
+SELECT
+  *,
+  level >= 5 AS senior,
+  (level + 1) <= 2 AS junior
+FROM
+  employees
+WHERE
+  country IN ('US', 'CA', 'MX') AND level BETWEEN 1 AND 10
@@ -0,0 +1,7 @@
+SELECT
+  first_name,
+  salary + bonus AS gross
+FROM
+  employees
+WHERE
+  age > 30
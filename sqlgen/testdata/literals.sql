@@ -0,0 +1,11 @@
+SELECT
+  DATE '2022-12-31' AS d,
+  TIME '01:02:03' AS t,
+  TIMESTAMP '2022-12-31 01:02:03' AS ts,
+  created_at + INTERVAL '10 days' AS due,
+  1.5 AS f,
+  'it''s' AS s,
+  CONCAT('http://www.', domain, '/', page) AS url,
+  version() AS v
+FROM
+  events
@@ -0,0 +1,16 @@
+SELECT
+  *,
+  created_at + INTERVAL 10 DAY AS due,
+  created_at + INTERVAL 500000 MICROSECOND AS soon,
+  DATE '2022-12-31' AS d,
+  TIMESTAMP '2022-12-31 01:02:03' AS ts,
+  CONCAT('order ', id) AS label,
+  TRUE AS active,
+  name REGEXP '^A.*' AS matches,
+  total DIV page_size AS pages,
+  balance DIV -2 AS halved,
+  2 * (total DIV page_size) AS spread,
+  total DIV 2 DIV 2 AS quarter
+FROM
+  `Orders`
+LIMIT 10
@@ -0,0 +1,8 @@
+SELECT
+  *,
+  ROUND(amount * 1.1, 2) AS total,
+  UPPER(LOWER(customer)) AS name
+FROM
+  orders
+WHERE
+  country IN ('US', 'CA', 'MX') AND amount BETWEEN 1 AND 10
@@ -0,0 +1,17 @@
+WITH table_0 AS (
+  SELECT
+    *
+  FROM
+    employees
+  ORDER BY
+    id
+  LIMIT 10 OFFSET 10
+)
+SELECT
+  *
+FROM
+  table_0
+WHERE
+  age < 40
+ORDER BY
+  id
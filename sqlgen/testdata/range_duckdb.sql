@@ -0,0 +1,7 @@
+SELECT
+  *
+FROM
+  employees
+ORDER BY
+  id
+OFFSET 5
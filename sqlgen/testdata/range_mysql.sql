@@ -0,0 +1,7 @@
+SELECT
+  *
+FROM
+  employees
+ORDER BY
+  id
+LIMIT 18446744073709551615 OFFSET 5
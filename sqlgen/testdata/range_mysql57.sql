@@ -0,0 +1,16 @@
+SELECT
+  *
+FROM
+  (
+    SELECT
+      *
+    FROM
+      employees
+    ORDER BY
+      id
+    LIMIT 5 OFFSET 5
+  ) AS table_0
+WHERE
+  age < 40
+ORDER BY
+  id
@@ -0,0 +1,7 @@
+SELECT
+  *
+FROM
+  employees
+ORDER BY
+  id
+LIMIT -1 OFFSET 5
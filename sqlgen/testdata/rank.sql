@@ -0,0 +1,5 @@
+SELECT
+  *,
+  RANK() OVER (PARTITION BY dept ORDER BY salary) AS r
+FROM
+  employees
@@ -0,0 +1,9 @@
+SELECT
+  *,
+  notes LIKE '%vip%' AS vip,
+  LOWER(notes) LIKE CONCAT('%', REPLACE(REPLACE(REPLACE(keyword, '!', '!!'), '%', '!%'), '_', '!_'), '%') ESCAPE '!' AS own,
+  code ~ pattern = (title LIKE 'Dr.%') AS misc
+FROM
+  customers
+WHERE
+  name ~ '^A.*' AND email LIKE '%@example.com' AND (discount LIKE '%50!%%' ESCAPE '!' OR sku LIKE 'A!_!!%' ESCAPE '!')
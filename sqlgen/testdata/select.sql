@@ -0,0 +1,8 @@
+SELECT
+  first_name,
+  "Last Name",
+  e.age,
+  e.id,
+  "user"
+FROM
+  employees AS e
@@ -0,0 +1,20 @@
+WITH table_0 AS (
+  SELECT
+    *,
+    salary + bonus AS gross
+  FROM
+    employees
+  ORDER BY
+    gross DESC,
+    age,
+    last_name
+  LIMIT 10
+)
+SELECT
+  *
+FROM
+  table_0
+WHERE
+  age > 30
+ORDER BY
+  first_name
@@ -0,0 +1,17 @@
+SELECT
+  *,
+  DATE('2022-12-31') AS d,
+  TIME('01:02:03') AS t,
+  DATETIME('2022-12-31 01:02:03') AS ts,
+  'order ' || id AS label,
+  1 AS active,
+  0 AS archived,
+  name LIKE REPLACE(REPLACE(REPLACE(prefix, '!', '!!'), '%', '!%'), '_', '!_') || '%' ESCAPE '!' AS prefixed,
+  paid * 1.0 / total AS ratio,
+  CAST(total * 1.0 / page_size AS INTEGER) AS pages,
+  CAST(balance * 1.0 / -2 AS INTEGER) AS halved,
+  LENGTH(name) AS name_length,
+  TRIM(TRIM(code, ' '), '-') AS code
+FROM
+  "Orders"
+LIMIT 10
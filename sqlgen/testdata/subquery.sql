@@ -0,0 +1,20 @@
+SELECT
+  *
+FROM
+  (
+    SELECT
+      *
+    FROM
+      (
+        SELECT
+          *,
+          salary + bonus AS gross
+        FROM
+          employees
+      ) AS table_0
+    WHERE
+      gross > 1000
+    LIMIT 10
+  ) AS table_1
+WHERE
+  age < 40
@@ -0,0 +1,6 @@
+SELECT
+  first_name,
+  FALSE AS active
+FROM
+  employees
+LIMIT 5
@@ -0,0 +1,13 @@
+SELECT
+  *,
+  AVG(close) OVER (ORDER BY date ROWS BETWEEN 3 PRECEDING AND CURRENT ROW) AS rolling,
+  ROW_NUMBER() OVER (PARTITION BY ticker ORDER BY date DESC) AS position,
+  LAG(close, 1) OVER (PARTITION BY ticker ORDER BY date DESC) AS previous,
+  COUNT(close) OVER (PARTITION BY ticker ORDER BY date DESC RANGE BETWEEN 1.5 PRECEDING AND 1.5 FOLLOWING) AS nearby,
+  SUM(volume) OVER (ORDER BY date ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS total,
+  MAX(close) OVER (PARTITION BY ticker ROWS BETWEEN 4 PRECEDING AND CURRENT ROW) AS recent
+FROM
+  prices
+ORDER BY
+  date
+LIMIT 100
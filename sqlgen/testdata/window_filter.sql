@@ -0,0 +1,21 @@
+WITH table_0 AS (
+  SELECT
+    *,
+    SUM(salary) OVER () AS total
+  FROM
+    employees
+),
+table_1 AS (
+  SELECT
+    *
+  FROM
+    table_0
+  WHERE
+    salary > 1000
+  LIMIT 5
+)
+SELECT
+  *,
+  LAG(salary, 1) OVER () AS previous
+FROM
+  table_1
@@ -0,0 +1,183 @@
+package sqlgen
+
+import (
+	"strings"
+
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/token"
+)
+
+// over is the window that aggregate and window functions are computed
+// over, in the pipelines of window and group transforms.
+type over struct {
+	partition []string // PARTITION BY keys
+	order     []string // ORDER BY keys
+	frame     string   // e.g. ROWS BETWEEN 3 PRECEDING AND CURRENT ROW
+}
+
+// clause returns the OVER clause of a function. Frames only apply to
+// aggregate functions, as ranking and offset functions ignore them.
+func (w *over) clause(aggregate bool) string {
+	if w == nil {
+		return "OVER ()"
+	}
+	var parts []string
+	if len(w.partition) > 0 {
+		parts = append(parts, "PARTITION BY "+strings.Join(w.partition, ", "))
+	}
+	if len(w.order) > 0 {
+		parts = append(parts, "ORDER BY "+strings.Join(w.order, ", "))
+	}
+	if aggregate && w.frame != "" {
+		parts = append(parts, w.frame)
+	}
+	return "OVER (" + strings.Join(parts, " ") + ")"
+}
+
+// window translates a window transform, whose frame replaces the frame of
+// w.
+func (g *generator) window(q *query, w over, node ast.WindowTransform) *query {
+	w.frame = g.frame(node)
+	return g.pipeline(q, w, node.Pipeline)
+}
+
+// group translates a group transform, whose keys partition the rows of
+// the windows in its pipeline.
+func (g *generator) group(q *query, w over, node ast.GroupTransform) *query {
+	if q.references(node.By) {
+		q = g.split(q)
+	}
+	w.partition = make([]string, len(node.By.Items))
+	for i, item := range node.By.Items {
+		w.partition[i] = g.expr(item)
+	}
+	return g.pipeline(q, w, node.Pipeline)
+}
+
+// pipeline translates the nested pipeline of a window or group transform.
+// Sort orders the rows of the window rather than the result, and derive
+// computes its aggregate and window functions over the window.
+func (g *generator) pipeline(q *query, w over, transforms []ast.Node) *query {
+	for _, node := range transforms {
+		switch node := node.(type) {
+		case ast.DeriveTransform:
+			var outer = g.over
+			g.over = &w
+			q = g.derive(q, node.List)
+			g.over = outer
+		case ast.SortTransform:
+			// window functions cannot order by the columns computed
+			// next to them
+			if q.references(node.List) {
+				q = g.split(q)
+			}
+			w.order = g.sortKeys(node.List)
+		case ast.WindowTransform:
+			q = g.window(q, w, node)
+		case ast.GroupTransform:
+			if len(w.partition) > 0 {
+				errorf("group is not supported in the pipeline of group")
+			}
+			q = g.group(q, w, node)
+		default:
+			errorf("unsupported transform %T in a window or group pipeline", node)
+		}
+	}
+	return q
+}
+
+// frame returns the frame clause of the named arguments of a window
+// transform: rows or range, which take a range of offsets from the current
+// row, expanding:true, or rolling:n for the n rows up to the current one.
+func (g *generator) frame(node ast.WindowTransform) string {
+	var frame string
+	for i, arg := range node.Named {
+		if i > 0 {
+			errorf("window expects one of rows, range, expanding and rolling")
+		}
+		switch arg.Name.Name {
+		case "rows", "range":
+			var r, ok = arg.Value.(ast.RangeExpr)
+			if !ok {
+				errorf("window %s expects a range, got %T", arg.Name.Name, arg.Value)
+			}
+			frame = strings.ToUpper(arg.Name.Name) + " BETWEEN " +
+				g.bound(arg.Name.Name, r.Start, "UNBOUNDED PRECEDING") + " AND " +
+				g.bound(arg.Name.Name, r.End, "UNBOUNDED FOLLOWING")
+		case "expanding":
+			var b, ok = unparen(arg.Value).(ast.Boolean)
+			if !ok {
+				errorf("window expanding expects a boolean, got %T", arg.Value)
+			}
+			if b.Value {
+				frame = "ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW"
+			}
+		case "rolling":
+			var n, ok = unparen(arg.Value).(ast.Integer)
+			if !ok || n.Value < 1 {
+				errorf("window rolling expects a positive integer")
+			}
+			frame = "ROWS BETWEEN " + g.bound("rows", ast.UnaryExpr{X: ast.Integer{Value: n.Value - 1}, Op: token.SUB}, "") + " AND CURRENT ROW"
+		default:
+			errorf("window has no parameter %s", arg.Name.Name)
+		}
+	}
+	return frame
+}
+
+// bound returns the frame bound of an offset from the current row, or
+// unbounded if offset is nil. Rows take integer offsets, and ranges also
+// take float and interval offsets.
+func (g *generator) bound(unit string, offset ast.Expr, unbounded string) string {
+	if offset == nil {
+		return unbounded
+	}
+	var direction = "FOLLOWING"
+	var x = unparen(offset)
+	if unary, ok := x.(ast.UnaryExpr); ok {
+		if unary.Op == token.SUB {
+			direction = "PRECEDING"
+		}
+		x = unparen(unary.X)
+	}
+
+	var flip = func() {
+		if direction == "PRECEDING" {
+			direction = "FOLLOWING"
+		} else {
+			direction = "PRECEDING"
+		}
+	}
+	switch x := x.(type) {
+	case ast.Integer:
+		if x.Value == 0 {
+			return "CURRENT ROW"
+		}
+		if x.Value < 0 {
+			x.Value = -x.Value
+			flip()
+		}
+		return g.expr(x) + " " + direction
+	case ast.Float:
+		if unit == "range" {
+			if x.Value < 0 {
+				x.Value = -x.Value
+				flip()
+			}
+			return g.expr(x) + " " + direction
+		}
+	case ast.Interval:
+		if unit == "range" {
+			if x.Count < 0 {
+				x.Count = -x.Count
+				flip()
+			}
+			return g.expr(x) + " " + direction
+		}
+	}
+	if unit == "range" {
+		errorf("window range expects number or interval bounds, got %T", x)
+	}
+	errorf("window rows expects integer bounds, got %T", x)
+	return ""
+}
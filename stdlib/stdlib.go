@@ -0,0 +1,189 @@
+// Package stdlib describes the functions and transforms of the PRQL
+// standard library, for the parser, the type checker and the code
+// generators.
+package stdlib
+
+import (
+	"fmt"
+
+	"github.com/siadat/prql-parser/ast"
+)
+
+// Param is a parameter of a function or transform. Type is the name of a
+// type of the types package, or "number" for int and float, "range" or
+// "any". Named parameters have a Default, positional parameters and ranges
+// do not.
+type Param struct {
+	Name    string
+	Type    string
+	Default ast.Expr
+}
+
+// Func is a function, e.g. round n_digits column.
+type Func struct {
+	Name   string
+	Params []Param
+	Named  []Param
+	// Aggregate functions reduce the rows of a group to a single value, and
+	// Window functions are computed from the rows around the current one.
+	// Both are computed over a window when used in derive or select.
+	Aggregate bool
+	Window    bool
+	// Returns is the name of the type of the result, or "" for the type of
+	// the last positional argument.
+	Returns string
+	// SQL is the template of the generated SQL, in which {name} is replaced
+	// by the argument of the parameter name.
+	SQL string
+}
+
+// Transform is a pipeline transform, e.g. derive columns.
+type Transform struct {
+	Name   string
+	Params []Param
+	Named  []Param
+}
+
+var (
+	From   = &Transform{Name: "from", Params: []Param{{Name: "table", Type: "any"}}}
+	Select = &Transform{Name: "select", Params: []Param{{Name: "columns", Type: "any"}}}
+	Derive = &Transform{Name: "derive", Params: []Param{{Name: "columns", Type: "any"}}}
+	Filter = &Transform{Name: "filter", Params: []Param{{Name: "condition", Type: "bool"}}}
+	Sort   = &Transform{Name: "sort", Params: []Param{{Name: "by", Type: "any"}}}
+	Take   = &Transform{Name: "take", Params: []Param{{Name: "n", Type: "int"}}}
+	Window = &Transform{
+		Name:   "window",
+		Params: []Param{{Name: "pipeline", Type: "any"}},
+		Named: []Param{
+			{Name: "rows", Type: "range"},
+			{Name: "range", Type: "range"},
+			{Name: "expanding", Type: "bool", Default: ast.Boolean{Value: false}},
+			{Name: "rolling", Type: "int", Default: ast.Integer{Value: 0}},
+		},
+	}
+	Group = &Transform{Name: "group", Params: []Param{{Name: "by", Type: "any"}, {Name: "pipeline", Type: "any"}}}
+)
+
+// Transforms lists the transforms of the standard library.
+var Transforms = []*Transform{From, Select, Derive, Filter, Sort, Take, Window, Group}
+
+func column(typ string) []Param {
+	return []Param{{Name: "column", Type: typ}}
+}
+
+// Funcs lists the functions of the standard library.
+var Funcs = []*Func{
+	{Name: "sum", Params: column("number"), Aggregate: true, SQL: "SUM({column})"},
+	{Name: "average", Params: column("number"), Aggregate: true, Returns: "float", SQL: "AVG({column})"},
+	{Name: "min", Params: column("any"), Aggregate: true, SQL: "MIN({column})"},
+	{Name: "max", Params: column("any"), Aggregate: true, SQL: "MAX({column})"},
+	{Name: "stddev", Params: column("number"), Aggregate: true, Returns: "float", SQL: "STDDEV({column})"},
+	{Name: "count", Params: column("any"), Aggregate: true, Returns: "int", SQL: "COUNT({column})"},
+	{Name: "count_distinct", Params: column("any"), Aggregate: true, Returns: "int", SQL: "COUNT(DISTINCT {column})"},
+
+	// the ranking functions number the rows in the order of the window
+	{Name: "row_number", Window: true, Returns: "int", SQL: "ROW_NUMBER()"},
+	{Name: "rank", Window: true, Returns: "int", SQL: "RANK()"},
+	{
+		Name:   "lag",
+		Params: []Param{{Name: "offset", Type: "int"}, {Name: "column", Type: "any"}},
+		Window: true,
+		SQL:    "LAG({column}, {offset})",
+	},
+	{
+		Name:   "lead",
+		Params: []Param{{Name: "offset", Type: "int"}, {Name: "column", Type: "any"}},
+		Window: true,
+		SQL:    "LEAD({column}, {offset})",
+	},
+
+	{Name: "round", Params: []Param{{Name: "n_digits", Type: "int"}, {Name: "column", Type: "number"}}, SQL: "ROUND({column}, {n_digits})"},
+	{Name: "abs", Params: column("number"), SQL: "ABS({column})"},
+	{Name: "floor", Params: column("number"), Returns: "int", SQL: "FLOOR({column})"},
+	{Name: "ceil", Params: column("number"), Returns: "int", SQL: "CEIL({column})"},
+	{Name: "lower", Params: column("string"), SQL: "LOWER({column})"},
+	{Name: "upper", Params: column("string"), SQL: "UPPER({column})"},
+
+	// length and trim have no template, as their SQL depends on the
+	// dialect
+	{Name: "length", Params: column("string"), Returns: "int"},
+	{
+		Name:   "trim",
+		Params: column("string"),
+		Named:  []Param{{Name: "chars", Type: "string", Default: ast.String{Value: `" "`}}},
+	},
+
+	// in has no template, as it is rendered as IN or BETWEEN depending on
+	// whether its pattern is an array or a range.
+	{Name: "in", Params: []Param{{Name: "pattern", Type: "any"}, {Name: "value", Type: "any"}}, Returns: "bool"},
+
+	// the text functions are rendered as LIKE with wildcards around the
+	// searched string
+	{Name: "text.contains", Params: []Param{{Name: "substr", Type: "string"}, {Name: "column", Type: "string"}}, Returns: "bool"},
+	{Name: "text.starts_with", Params: []Param{{Name: "prefix", Type: "string"}, {Name: "column", Type: "string"}}, Returns: "bool"},
+	{Name: "text.ends_with", Params: []Param{{Name: "suffix", Type: "string"}, {Name: "column", Type: "string"}}, Returns: "bool"},
+}
+
+// LookupFunc returns the function with the given name.
+func LookupFunc(name string) (*Func, bool) {
+	for _, f := range Funcs {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// LookupTransform returns the transform with the given name.
+func LookupTransform(name string) (*Transform, bool) {
+	for _, t := range Transforms {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// Lookup returns the named parameter of t with the given name.
+func (t *Transform) Lookup(name string) (Param, bool) {
+	for _, param := range t.Named {
+		if param.Name == name {
+			return param, true
+		}
+	}
+	return Param{}, false
+}
+
+// Bind returns the arguments of a call of f by parameter, in the order of
+// Params followed by Named. Named parameters that are not given are bound to
+// their defaults.
+func (f *Func) Bind(call ast.CallExpr) ([]ast.Expr, error) {
+	if len(call.Args) != len(f.Params) {
+		return nil, fmt.Errorf("%s expects %d arguments, got %d", f.Name, len(f.Params), len(call.Args))
+	}
+	var args = append([]ast.Expr(nil), call.Args...)
+	for _, param := range f.Named {
+		args = append(args, param.Default)
+	}
+	for _, arg := range call.Named {
+		var found = false
+		for i, param := range f.Named {
+			if param.Name == arg.Name.Name {
+				args[len(f.Params)+i] = arg.Value
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%s has no parameter %s", f.Name, arg.Name.Name)
+		}
+	}
+	return args, nil
+}
+
+// Param returns the parameter at index i of the arguments returned by Bind.
+func (f *Func) Param(i int) Param {
+	if i < len(f.Params) {
+		return f.Params[i]
+	}
+	return f.Named[i-len(f.Params)]
+}
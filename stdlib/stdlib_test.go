@@ -0,0 +1,75 @@
+package stdlib_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/printer"
+	"github.com/siadat/prql-parser/stdlib"
+)
+
+func TestBind(tt *testing.T) {
+	var testCases = []struct {
+		call ast.CallExpr
+		want string
+		err  string
+	}{
+		{
+			call: call("round", nil, ast.Integer{Value: 2}, column("price")),
+			want: "2 price",
+		},
+		{
+			call: call("trim", nil, column("name")),
+			want: `name " "`,
+		},
+		{
+			call: call("trim", []ast.NamedArg{{Name: ast.Ident{Name: "chars"}, Value: ast.String{Value: `"-"`}}}, column("name")),
+			want: `name "-"`,
+		},
+		{
+			call: call("round", nil, column("price")),
+			err:  "round expects 2 arguments, got 1",
+		},
+		{
+			call: call("trim", []ast.NamedArg{{Name: ast.Ident{Name: "width"}, Value: ast.Integer{Value: 2}}}, column("name")),
+			err:  "trim has no parameter width",
+		},
+	}
+
+	for _, tc := range testCases {
+		var f, ok = stdlib.LookupFunc(tc.call.Name.Name)
+		if !ok {
+			tt.Fatalf("unknown function %s", tc.call.Name.Name)
+		}
+		var args, err = f.Bind(tc.call)
+		if tc.err != "" {
+			if err == nil || err.Error() != tc.err {
+				tt.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			tt.Fatalf("%s: unexpected error: %v", tc.call.Name.Name, err)
+		}
+		var got []string
+		for _, arg := range args {
+			var b strings.Builder
+			if err := printer.Fprint(&b, arg); err != nil {
+				tt.Fatal(err)
+			}
+			got = append(got, b.String())
+		}
+		if strings.Join(got, " ") != tc.want {
+			tt.Fatalf("%s: want %q, got %q", tc.call.Name.Name, tc.want, strings.Join(got, " "))
+		}
+	}
+}
+
+func call(name string, named []ast.NamedArg, args ...ast.Expr) ast.CallExpr {
+	return ast.CallExpr{Name: ast.Ident{Name: name}, Args: args, Named: named}
+}
+
+func column(name string) ast.Expr {
+	return ast.Column{Name: ast.Ident{Name: name}}
+}
@@ -54,6 +54,11 @@ const (
 	OR       // or
 	COALESCE // ??
 	ARROW    // ->
+	RANGE    // ..
+	FATARROW // =>
+	REGEX    // ~=
+	INTDIV   // //
+	POW      // **
 	operator_end
 
 	keyword_beg
@@ -115,6 +120,11 @@ var tokens = [...]string{
 	OR:       "OR",
 	COALESCE: "COALESCE",
 	ARROW:    "ARROW",
+	RANGE:    "RANGE",
+	FATARROW: "FATARROW",
+	REGEX:    "REGEX",
+	INTDIV:   "INTDIV",
+	POW:      "POW",
 
 	FUNC:  "FUNC",
 	TABLE: "TABLE",
@@ -138,10 +148,28 @@ type Precedence int
 
 var LowestPrecedence Precedence = 0
 var Precedences = map[Token]Precedence{
-	ADD: 1,
-	SUB: 1,
-	MUL: 2,
-	QUO: 2,
+	OR:     1,
+	AND:    2,
+	EQL:    3,
+	NEQ:    3,
+	LSS:    3,
+	GTR:    3,
+	LEQ:    3,
+	GEQ:    3,
+	REGEX:  3,
+	ADD:    4,
+	SUB:    4,
+	MUL:    5,
+	QUO:    5,
+	INTDIV: 5,
+	POW:    6,
+}
+
+// RightAssociative reports whether a chain of the binary operator tok groups
+// to the right, e.g. 2 ** 3 ** 2 is 2 ** (3 ** 2). The other operators group
+// to the left.
+func (tok Token) RightAssociative() bool {
+	return tok == POW
 }
 
 func (tok Token) String() string {
@@ -166,3 +194,17 @@ func (lit LiteralStringer) String() string {
 	}
 	return fmt.Sprintf("%q", string(lit))
 }
+
+func (tok Token) MarshalText() ([]byte, error) {
+	return []byte(tok.String()), nil
+}
+
+func (tok *Token) UnmarshalText(text []byte) error {
+	for i, s := range tokens {
+		if s != "" && s == string(text) {
+			*tok = Token(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown token %q", text)
+}
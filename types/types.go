@@ -0,0 +1,514 @@
+// Package types infers and checks the types of the expressions of PRQL
+// queries.
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/catalog"
+	"github.com/siadat/prql-parser/desugar"
+	"github.com/siadat/prql-parser/resolver"
+	"github.com/siadat/prql-parser/scanner"
+	"github.com/siadat/prql-parser/stdlib"
+	"github.com/siadat/prql-parser/token"
+)
+
+type Type int
+
+const (
+	// Unknown is the type of columns of tables that are not in the
+	// catalog, of s-strings and of the expressions using them. It is
+	// compatible with every other type.
+	Unknown Type = iota
+	Int
+	Float
+	Bool
+	String
+	Date
+	Time
+	Timestamp
+	Interval
+)
+
+var typeNames = [...]string{
+	Unknown:   "unknown",
+	Int:       "int",
+	Float:     "float",
+	Bool:      "bool",
+	String:    "string",
+	Date:      "date",
+	Time:      "time",
+	Timestamp: "timestamp",
+	Interval:  "interval",
+}
+
+func (t Type) String() string {
+	if 0 <= t && int(t) < len(typeNames) {
+		return typeNames[t]
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+func (t Type) numeric() bool {
+	return t == Int || t == Float
+}
+
+var sqlTypes = map[string]Type{
+	"int": Int, "integer": Int, "smallint": Int, "bigint": Int, "tinyint": Int,
+	"mediumint": Int, "int2": Int, "int4": Int, "int8": Int, "hugeint": Int,
+	"serial": Int, "smallserial": Int, "bigserial": Int,
+
+	"real": Float, "float": Float, "float4": Float, "float8": Float,
+	"double": Float, "double precision": Float, "numeric": Float, "decimal": Float,
+
+	"bool": Bool, "boolean": Bool,
+
+	"text": String, "varchar": String, "char": String, "character": String,
+	"character varying": String, "nvarchar": String, "nchar": String,
+	"string": String, "clob": String,
+
+	"date": Date,
+
+	"time": Time, "timetz": Time, "time with time zone": Time,
+	"time without time zone": Time,
+
+	"timestamp": Timestamp, "timestamptz": Timestamp, "datetime": Timestamp,
+	"timestamp with time zone": Timestamp, "timestamp without time zone": Timestamp,
+
+	"interval": Interval,
+}
+
+// FromSQL returns the type of columns declared with the SQL type sqlType,
+// e.g. Int for "bigint" and String for "varchar(20)". Types that are not
+// known, and arrays, are Unknown.
+func FromSQL(sqlType string) Type {
+	var name = strings.ToLower(strings.TrimSpace(sqlType))
+	if i := strings.IndexByte(name, '('); i >= 0 && !strings.HasSuffix(name, "[]") {
+		// drop the arguments, e.g. in numeric(10,2) or timestamp(3) with time zone
+		var j = strings.IndexByte(name[i:], ')')
+		if j < 0 {
+			return Unknown
+		}
+		name = strings.TrimSpace(name[:i] + name[i+j+1:])
+	}
+	return sqlTypes[strings.Join(strings.Fields(name), " ")]
+}
+
+// Column is a column of the output of a pipeline.
+type Column struct {
+	Name string
+	Type Type
+}
+
+// Info holds the results of Check.
+type Info struct {
+	// Decls holds the types of the columns declared by the assignments of
+	// derive and select, by the path of the assignment.
+	Decls map[ast.NodePath]Type
+	// Output lists the columns after the last transform. The columns of a
+	// table that is not in the catalog are only listed if they are used.
+	Output []Column
+}
+
+// Error is a type error, or an unknown table or column. Pos is -1 for
+// expressions that do not have a position, like literals.
+type Error struct {
+	Pos scanner.Pos
+	Msg string
+}
+
+func (e Error) Error() string {
+	if e.Pos < 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf("%s at %d", e.Msg, e.Pos)
+}
+
+type checker struct {
+	catalog  *catalog.Catalog
+	bindings *resolver.Info
+	info     *Info
+	errs     []Error
+	next     int // the Item of the next list item of the transform, see resolver
+}
+
+// Check infers the types of the expressions of root. Columns are resolved
+// with resolver.Resolve, and the types of table columns are taken from cat,
+// which may be nil.
+func Check(root *ast.Root, cat *catalog.Catalog) (*Info, []Error) {
+	var schema resolver.Schema
+	if cat != nil {
+		schema = cat.Schema()
+	}
+	root = desugar.Root(root)
+	var bindings, resolveErrs = resolver.Resolve(root, schema)
+
+	var c = &checker{
+		catalog:  cat,
+		bindings: bindings,
+		info:     &Info{Decls: map[ast.NodePath]Type{}},
+	}
+	for _, err := range resolveErrs {
+		c.errs = append(c.errs, Error{Pos: err.Pos, Msg: fmt.Sprintf("unknown %s %s", err.Kind, err.Name)})
+	}
+
+	for i, node := range root.Transforms {
+		c.next = 0
+		c.transform(i, node)
+	}
+	if n := len(bindings.Scopes); n > 0 {
+		for _, b := range bindings.Scopes[n-1].Columns {
+			c.info.Output = append(c.info.Output, Column{Name: b.Name, Type: c.binding(b)})
+		}
+	}
+	return c.info, c.errs
+}
+
+func (c *checker) errorf(pos scanner.Pos, format string, args ...interface{}) {
+	c.errs = append(c.errs, Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) transform(i int, node ast.Node) {
+	var path = ast.NodePath{Transform: i, Item: -1}
+	switch node := node.(type) {
+	case ast.DeriveTransform:
+		c.list(path, node.List)
+	case ast.SelectTransform:
+		c.list(path, node.List)
+	case ast.SortTransform:
+		c.list(path, node.List)
+	case ast.FilterTransform:
+		if t := c.expr(node.Expr); t != Bool && t != Unknown {
+			c.errorf(pos(node.Expr), "filter condition must be bool, got %s", t)
+		}
+	case ast.TakeTransform:
+		// a number of rows, or a range of them
+		var counts = []ast.Expr{node.Expr}
+		if r, ok := node.Expr.(ast.RangeExpr); ok {
+			counts = []ast.Expr{r.Start, r.End}
+		}
+		for _, count := range counts {
+			if count == nil {
+				continue
+			}
+			if t := c.expr(count); t != Int && t != Unknown {
+				c.errorf(pos(count), "take expects int, got %s", t)
+			}
+		}
+	case ast.WindowTransform:
+		for _, arg := range node.Named {
+			var t = c.expr(arg.Value)
+			if param, ok := stdlib.Window.Lookup(arg.Name.Name); ok && param.Type != "range" && !accepts(param.Type, t) {
+				c.errorf(arg.Name.Pos, "window %s expects %s, got %s", param.Name, param.Type, t)
+			}
+		}
+		for _, t := range node.Pipeline {
+			c.transform(i, t)
+		}
+	case ast.GroupTransform:
+		c.list(path, node.By)
+		for _, t := range node.Pipeline {
+			c.transform(i, t)
+		}
+	}
+}
+
+func (c *checker) list(path ast.NodePath, list ast.ExprList) {
+	for _, item := range list.Items {
+		path.Item = c.next
+		c.next++
+		if assign, ok := item.(ast.AssignExpr); ok {
+			c.info.Decls[path] = c.expr(assign.Expr)
+		} else {
+			c.expr(item)
+		}
+	}
+}
+
+// binding returns the type of a resolved column.
+func (c *checker) binding(b resolver.Binding) Type {
+	if b.Table == "" {
+		return c.info.Decls[b.Decl]
+	}
+	if c.catalog == nil {
+		return Unknown
+	}
+	var t, ok = c.catalog.Table(b.Table)
+	if !ok {
+		return Unknown
+	}
+	for _, col := range t.Columns {
+		if col.Name == b.Name {
+			return FromSQL(col.Type)
+		}
+	}
+	return Unknown
+}
+
+func (c *checker) expr(e ast.Expr) Type {
+	switch e := e.(type) {
+	case ast.Column:
+		if b, ok := c.bindings.Lookup(e); ok {
+			return c.binding(b)
+		}
+		return Unknown
+	case ast.Integer:
+		return Int
+	case ast.Float:
+		return Float
+	case ast.Boolean:
+		return Bool
+	case ast.Date:
+		return Date
+	case ast.Time:
+		return Time
+	case ast.Timestamp:
+		return Timestamp
+	case ast.Interval:
+		return Interval
+	case ast.String:
+		if strings.HasPrefix(e.Value, "s") {
+			return Unknown
+		}
+		return String
+	case ast.ParenExpr:
+		return c.expr(e.X)
+	case ast.AssignExpr:
+		return c.expr(e.Expr)
+	case ast.UnaryExpr:
+		var x = c.expr(e.X)
+		if x == Unknown || x.numeric() || x == Interval {
+			return x
+		}
+		c.errorf(pos(e), "invalid operation: %s%s", opString(e.Op), x)
+		return Unknown
+	case ast.BinaryExpr:
+		var x, y = c.expr(e.X), c.expr(e.Y)
+		var t, ok = binary(e.Op, x, y)
+		if !ok {
+			c.errorf(e.OpPos, "invalid operation: %s %s %s", x, opString(e.Op), y)
+		}
+		return t
+	case ast.CallExpr:
+		return c.call(e)
+	case ast.CaseExpr:
+		return c.caseExpr(e)
+	case ast.ExprList:
+		for _, item := range e.Items {
+			c.expr(item)
+		}
+		return Unknown
+	case ast.Array:
+		for _, item := range e.Items {
+			c.expr(item)
+		}
+		return Unknown
+	case ast.FuncLit:
+		// the types of the parameters are not known until the function is
+		// called
+		c.expr(e.Body)
+		return Unknown
+	case ast.RangeExpr:
+		for _, bound := range []ast.Expr{e.Start, e.End} {
+			if bound == nil {
+				continue
+			}
+			if t := c.expr(bound); t != Unknown && !t.numeric() && t != Interval {
+				c.errorf(pos(bound), "range bounds must be numbers or intervals, got %s", t)
+			}
+		}
+		return Unknown
+	}
+	return Unknown
+}
+
+// call checks the arguments of a call against the parameters of the
+// function, and returns the type of its result.
+func (c *checker) call(call ast.CallExpr) Type {
+	var f, ok = stdlib.LookupFunc(call.Name.Name)
+	if !ok {
+		c.errorf(call.Name.Pos, "unknown function %s", call.Name.Name)
+		return Unknown
+	}
+	var args, err = f.Bind(call)
+	if err != nil {
+		c.errorf(call.Name.Pos, "%v", err)
+		return Unknown
+	}
+
+	var last = Unknown
+	for i, arg := range args {
+		var t = c.expr(arg)
+		var param = f.Param(i)
+		if !accepts(param.Type, t) {
+			c.errorf(call.Name.Pos, "%s: %s must be %s, got %s", f.Name, param.Name, param.Type, t)
+		}
+		if i < len(f.Params) {
+			last = t
+		}
+	}
+	if f.Returns == "" {
+		return last
+	}
+	return lookup(f.Returns)
+}
+
+// caseExpr checks that the conditions of the arms are bool and that the
+// values have the same type, and returns that type. Int and float values
+// make a float.
+func (c *checker) caseExpr(e ast.CaseExpr) Type {
+	var result = Unknown
+	for _, arm := range e.Arms {
+		if t := c.expr(arm.Cond); t != Bool && t != Unknown {
+			var p = pos(arm.Cond)
+			if p < 0 {
+				p = e.Case
+			}
+			c.errorf(p, "case condition must be bool, got %s", t)
+		}
+		switch t := c.expr(arm.Value); {
+		case t == Unknown || t == result:
+		case result == Unknown:
+			result = t
+		case result.numeric() && t.numeric():
+			result = Float
+		default:
+			c.errorf(e.Case, "case arms have different types %s and %s", result, t)
+		}
+	}
+	return result
+}
+
+// accepts reports whether an argument of type t can be passed to a
+// parameter of the stdlib type typ.
+func accepts(typ string, t Type) bool {
+	switch typ {
+	case "any":
+		return true
+	case "number":
+		return t == Unknown || t.numeric()
+	}
+	return t == Unknown || t == lookup(typ)
+}
+
+// lookup returns the type with the given name.
+func lookup(name string) Type {
+	for t, n := range typeNames {
+		if n == name {
+			return Type(t)
+		}
+	}
+	return Unknown
+}
+
+// binary returns the type of x op y, and false if the operator is not
+// defined for the operand types.
+func binary(op token.Token, x, y Type) (Type, bool) {
+	switch op {
+	case token.AND, token.OR:
+		return Bool, (x == Bool || x == Unknown) && (y == Bool || y == Unknown)
+	case token.EQL, token.NEQ, token.LSS, token.GTR, token.LEQ, token.GEQ:
+		return Bool, x == Unknown || y == Unknown || x == y || (x.numeric() && y.numeric())
+	case token.REGEX:
+		return Bool, (x == String || x == Unknown) && (y == String || y == Unknown)
+	}
+
+	if x == Unknown || y == Unknown {
+		return Unknown, true
+	}
+	switch op {
+	case token.ADD, token.SUB:
+		switch {
+		case x == Int && y == Int:
+			return Int, true
+		case x.numeric() && y.numeric():
+			return Float, true
+		case x == Interval && y == Interval:
+			return Interval, true
+		case (x == Date || x == Time || x == Timestamp) && y == Interval:
+			return x, true
+		case op == token.ADD && x == Interval && (y == Date || y == Time || y == Timestamp):
+			return y, true
+		case op == token.SUB && x == Date && y == Date:
+			return Int, true
+		case op == token.SUB && x == Timestamp && y == Timestamp:
+			return Interval, true
+		}
+	case token.MUL:
+		switch {
+		case x == Int && y == Int:
+			return Int, true
+		case x.numeric() && y.numeric():
+			return Float, true
+		case x == Interval && y.numeric(), x.numeric() && y == Interval:
+			return Interval, true
+		}
+	case token.QUO:
+		switch {
+		case x.numeric() && y.numeric():
+			return Float, true
+		case x == Interval && y.numeric():
+			return Interval, true
+		}
+	case token.INTDIV:
+		// the truncated quotient of floats is a float in some dialects
+		switch {
+		case x == Int && y == Int:
+			return Int, true
+		case x.numeric() && y.numeric():
+			return Float, true
+		}
+	case token.POW:
+		if x.numeric() && y.numeric() {
+			return Float, true
+		}
+	}
+	return Unknown, false
+}
+
+var opStrings = map[token.Token]string{
+	token.ADD:    "+",
+	token.SUB:    "-",
+	token.MUL:    "*",
+	token.QUO:    "/",
+	token.EQL:    "==",
+	token.NEQ:    "!=",
+	token.LSS:    "<",
+	token.GTR:    ">",
+	token.LEQ:    "<=",
+	token.GEQ:    ">=",
+	token.AND:    "and",
+	token.OR:     "or",
+	token.REGEX:  "~=",
+	token.INTDIV: "//",
+	token.POW:    "**",
+}
+
+func opString(op token.Token) string {
+	if s, ok := opStrings[op]; ok {
+		return s
+	}
+	return op.String()
+}
+
+// pos returns the position of the operator or column of e, or -1 if e has
+// none.
+func pos(e ast.Expr) scanner.Pos {
+	switch e := e.(type) {
+	case ast.Column:
+		return e.Name.Pos
+	case ast.BinaryExpr:
+		return e.OpPos
+	case ast.CallExpr:
+		return e.Name.Pos
+	case ast.CaseExpr:
+		return e.Case
+	case ast.UnaryExpr:
+		return pos(e.X)
+	case ast.ParenExpr:
+		return pos(e.X)
+	}
+	return -1
+}
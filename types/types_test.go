@@ -0,0 +1,217 @@
+package types_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/siadat/prql-parser/ast"
+	"github.com/siadat/prql-parser/catalog"
+	"github.com/siadat/prql-parser/parser"
+	"github.com/siadat/prql-parser/types"
+)
+
+var cat = &catalog.Catalog{
+	Tables: []catalog.Table{
+		{
+			Name: "employees",
+			Columns: []catalog.Column{
+				{Name: "id", Type: "bigint"},
+				{Name: "name", Type: "varchar(100)"},
+				{Name: "salary", Type: "numeric(10,2)"},
+				{Name: "hired", Type: "date"},
+				{Name: "tags", Type: "text[]"},
+			},
+		},
+	},
+}
+
+func TestCheck(tt *testing.T) {
+	var testCases = []struct {
+		src  string
+		cat  *catalog.Catalog
+		want []types.Column
+		errs []string
+	}{
+		{
+			src: `
+from employees
+derive [half = id / 2, next = id + 1, anniversary = hired + 1years, t = tags]
+filter salary > 1000 and half <= 10
+select [name, half, next, anniversary, raised = salary * 1.1, t, elapsed = @2023-01-01 - hired]
+			`,
+			cat: cat,
+			want: []types.Column{
+				{Name: "name", Type: types.String},
+				{Name: "half", Type: types.Float},
+				{Name: "next", Type: types.Int},
+				{Name: "anniversary", Type: types.Date},
+				{Name: "raised", Type: types.Float},
+				{Name: "t", Type: types.Unknown},
+				{Name: "elapsed", Type: types.Int},
+			},
+		},
+		{
+			src: "from employees\nderive [a = name + 1, b = hired * 2, c = -name]\nfilter id\ntake 1.5",
+			cat: cat,
+			want: []types.Column{
+				{Name: "id", Type: types.Int},
+				{Name: "name", Type: types.String},
+				{Name: "salary", Type: types.Float},
+				{Name: "hired", Type: types.Date},
+				{Name: "tags", Type: types.Unknown},
+				{Name: "a", Type: types.Unknown},
+				{Name: "b", Type: types.Unknown},
+				{Name: "c", Type: types.Unknown},
+			},
+			errs: []string{
+				"invalid operation: string + int at 32",
+				"invalid operation: date * int at 47",
+				"invalid operation: -string at 57",
+				"filter condition must be bool, got int at 70",
+				"take expects int, got float",
+			},
+		},
+		{
+			src: "from employees\ntake 2..1.5",
+			cat: cat,
+			want: []types.Column{
+				{Name: "id", Type: types.Int},
+				{Name: "name", Type: types.String},
+				{Name: "salary", Type: types.Float},
+				{Name: "hired", Type: types.Date},
+				{Name: "tags", Type: types.Unknown},
+			},
+			errs: []string{
+				"take expects int, got float",
+			},
+		},
+		{
+			// without a catalog, the types of table columns are unknown
+			src: "from t\nderive [a = x + 1, b = x > 1 or 'text', c = s\"now()\" + 1]\nselect [a, b, c]",
+			want: []types.Column{
+				{Name: "a", Type: types.Unknown},
+				{Name: "b", Type: types.Bool},
+				{Name: "c", Type: types.Unknown},
+			},
+			errs: []string{
+				"invalid operation: bool or string at 36",
+			},
+		},
+		{
+			src: "from employees\nfilter name ~= \"^A\" and id ~= \"1\"\nselect [name]",
+			cat: cat,
+			want: []types.Column{
+				{Name: "name", Type: types.String},
+			},
+			errs: []string{
+				"invalid operation: int ~= string at 42",
+			},
+		},
+		{
+			src: "from employees\nselect [a = id // 2, b = salary // 2, c = id ** 2, d = name ** 2]",
+			cat: cat,
+			want: []types.Column{
+				{Name: "a", Type: types.Int},
+				{Name: "b", Type: types.Float},
+				{Name: "c", Type: types.Float},
+				{Name: "d", Type: types.Unknown},
+			},
+			errs: []string{
+				"invalid operation: string ** int at 75",
+			},
+		},
+		{
+			src: "from employees\nselect [nmae]",
+			cat: cat,
+			errs: []string{
+				"unknown column nmae at 23",
+			},
+		},
+		{
+			src: "from employees\nderive [a = case [salary > 10 => 1, true => 1.5], b = case [name => 1, id > 1 => \"x\"]]",
+			cat: cat,
+			want: []types.Column{
+				{Name: "id", Type: types.Int},
+				{Name: "name", Type: types.String},
+				{Name: "salary", Type: types.Float},
+				{Name: "hired", Type: types.Date},
+				{Name: "tags", Type: types.Unknown},
+				{Name: "a", Type: types.Float},
+				{Name: "b", Type: types.Int},
+			},
+			errs: []string{
+				"case condition must be bool, got string at 75",
+				"case arms have different types int and string at 69",
+			},
+		},
+		{
+			src: "from employees\ngroup dept (sort hired | window rolling:1.5 (derive [n = count id, r = round 1 salary]))\nselect [n, r]",
+			cat: cat,
+			want: []types.Column{
+				{Name: "n", Type: types.Int},
+				{Name: "r", Type: types.Float},
+			},
+			errs: []string{
+				"unknown column dept at 21",
+				"window rolling expects int, got float at 47",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		var p = parser.NewParser()
+		var root, err = p.Parse(strings.NewReader(tc.src))
+		if err != nil {
+			tt.Fatalf("test case failed\nsrc:\n%s\nerr: %v", tc.src, err)
+		}
+
+		var info, errs = types.Check(root, tc.cat)
+		if diff := cmp.Diff(tc.want, info.Output); diff != "" {
+			tt.Fatalf("mismatching results\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.src, diff)
+		}
+		var gotErrs []string
+		for _, err := range errs {
+			gotErrs = append(gotErrs, err.Error())
+		}
+		if diff := cmp.Diff(tc.errs, gotErrs); diff != "" {
+			tt.Fatalf("mismatching errors\nsrc:\n%s\ndiff guide:\n  - want\n  + got\ndiff:\n%s", tc.src, diff)
+		}
+	}
+}
+
+func TestDecls(tt *testing.T) {
+	var p = parser.NewParser()
+	var root, err = p.Parse(strings.NewReader("from employees\nderive [a = 1, b = a * 2.0]"))
+	if err != nil {
+		tt.Fatal(err)
+	}
+	var info, errs = types.Check(root, cat)
+	if len(errs) != 0 {
+		tt.Fatalf("unexpected errors %v", errs)
+	}
+	var want = map[ast.NodePath]types.Type{
+		{Transform: 1, Item: 0}: types.Int,
+		{Transform: 1, Item: 1}: types.Float,
+	}
+	if diff := cmp.Diff(want, info.Decls); diff != "" {
+		tt.Fatalf("mismatching results\ndiff guide:\n  - want\n  + got\ndiff:\n%s", diff)
+	}
+}
+
+func TestFromSQL(tt *testing.T) {
+	var testCases = map[string]types.Type{
+		"INTEGER":                     types.Int,
+		"numeric(10, 2)":              types.Float,
+		"Double  Precision":           types.Float,
+		"character varying(20)":       types.String,
+		"timestamp(3) with time zone": types.Timestamp,
+		"int[]":                       types.Unknown,
+		"uuid":                        types.Unknown,
+	}
+	for sqlType, want := range testCases {
+		if got := types.FromSQL(sqlType); got != want {
+			tt.Fatalf("FromSQL(%q) = %s, want %s", sqlType, got, want)
+		}
+	}
+}